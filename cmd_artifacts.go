@@ -0,0 +1,245 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+	"github.com/gregfurman/pipescope/internal/git"
+)
+
+// runArtifacts downloads the artifacts of the HEAD pipeline, optionally waiting for it to complete
+// and extracting the files within them.
+func runArtifacts(args []string) error {
+	fs := flag.NewFlagSet("artifacts", flag.ExitOnError)
+	opts := newOptions(fs)
+	fjob := fs.String("job", "", "Only download the artifacts of the job with this name, or on GitHub the artifact with this name.")
+	fpath := fs.String("path", "", "Only extract files within the artifacts matching this glob, e.g. 'bin/*'. Implies -unzip.")
+	fdir := fs.String("o", ".", "Directory to write artifacts to.")
+	funzip := fs.Bool("unzip", false, "Extract the artifacts into a directory per artifact rather than writing their zip archives.")
+	fwait := fs.Bool("wait", false, "Wait for the pipeline to complete before downloading its artifacts.")
+
+	fs.Parse(args) //nolint:errcheck
+
+	gitClient, err := git.New(opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	url, err := gitClient.GetRemoteURL()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(opts.configPath, opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	opts.applySettings(cfg.Resolve(gateway.ParseRemoteURL(url)))
+
+	if err := opts.setupLogger(); err != nil {
+		return err
+	}
+
+	client, err := opts.newGatewayClient(cfg, url)
+	if err != nil {
+		return err
+	}
+
+	downloader, ok := client.(gateway.ArtifactDownloader)
+	if !ok {
+		return errors.New("downloading artifacts is not supported by this provider")
+	}
+
+	svc := opts.newService(client, gitClient)
+
+	pipeline, err := svc.GetPipeline()
+	if err != nil {
+		return err
+	}
+
+	if *fwait && svc.IsPending(pipeline) {
+		if pipeline, err = awaitPipeline(opts, svc, pipeline); err != nil {
+			return err
+		}
+	}
+
+	artifacts, err := downloader.Artifacts(pipeline.ProjectID, pipeline.ID)
+	if err != nil {
+		return err
+	}
+
+	var downloaded int
+
+	for _, artifact := range artifacts {
+		if *fjob != "" && artifact.Job != *fjob && (artifact.Job != "" || artifact.Name != *fjob) {
+			continue
+		}
+
+		if artifact.Expired {
+			slog.Warn(fmt.Sprintf("Skipping expired artifact [name=%s]", artifact.Name))
+
+			continue
+		}
+
+		if err := downloadArtifact(downloader, pipeline.ProjectID, artifact, *fdir, *fpath, *funzip || *fpath != ""); err != nil {
+			return err
+		}
+
+		downloaded++
+	}
+
+	if downloaded == 0 {
+		return fmt.Errorf("pipeline %d has no artifacts to download", pipeline.ID)
+	}
+
+	return nil
+}
+
+// awaitPipeline watches a pipeline until it completes, returning it in its final state.
+func awaitPipeline(opts *options, svc *checker.Service, pipeline *gateway.Pipeline) (*gateway.Pipeline, error) {
+	notifiers, err := opts.notifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := pipelineLogger(pipeline)
+
+	for event := range svc.WatchPipeline(pipeline, opts.scheduler(svc)(pipeline)) {
+		report(logger, event, notifiers)
+
+		if event.Type == checker.EventError {
+			return nil, event.Err
+		}
+
+		pipeline = event.Pipeline
+	}
+
+	return pipeline, nil
+}
+
+// downloadArtifact writes an artifact's archive to dir as <name>.zip or, when unzipping, extracts
+// the files matching the glob pattern into dir/<name>, with separators in the name replaced. Written paths are printed to stdout.
+func downloadArtifact(downloader gateway.ArtifactDownloader, id string, artifact gateway.Artifact, dir, pattern string, unzip bool) error {
+	slog.Info(fmt.Sprintf("Downloading Artifact [name=%s, size=%d]", artifact.Name, artifact.Size))
+
+	archive, err := downloader.DownloadArtifact(id, artifact)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if !unzip {
+		dst := filepath.Join(dir, artifact.FileName()+".zip")
+		if err := writeFile(dst, archive, 0o644); err != nil {
+			return err
+		}
+
+		fmt.Println(dst)
+
+		return nil
+	}
+
+	// Zip archives are read from the end, so are buffered on disk first
+	tmp, err := os.CreateTemp("", "pipescope-artifact-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to buffer artifact: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, archive)
+	if err != nil {
+		return fmt.Errorf("failed to download artifact %s: %w", artifact.Name, err)
+	}
+
+	return extract(tmp, size, filepath.Join(dir, artifact.FileName()), pattern)
+}
+
+// extract writes the files of a zip archive matching the glob pattern, or every file if it is empty,
+// into dir.
+func extract(r io.ReaderAt, size int64, dir, pattern string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to read artifact archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		if pattern != "" {
+			if ok, err := path.Match(pattern, f.Name); err != nil {
+				return fmt.Errorf("malformed -path glob %q: %w", pattern, err)
+			} else if !ok {
+				continue
+			}
+		}
+
+		// Entries must not escape the output directory, e.g. through "../"
+		if !filepath.IsLocal(f.Name) {
+			return fmt.Errorf("artifact archive contains unsafe path %q", f.Name)
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		src, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s from artifact archive: %w", f.Name, err)
+		}
+
+		// Permissions are kept so that downloaded binaries remain executable
+		perm := f.Mode().Perm()
+		if perm == 0 {
+			perm = 0o644
+		}
+
+		err = writeFile(dst, src, perm)
+		src.Close()
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(dst)
+	}
+
+	return nil
+}
+
+func writeFile(dst string, r io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/auth"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+func runAuth(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: pipescope auth <login|status> [flags]")
+	}
+
+	switch args[0] {
+	case "login":
+		return runAuthLogin(args[1:])
+	case "status":
+		return runAuthStatus(args[1:])
+	default:
+		return fmt.Errorf("unknown auth command %q: must be one of login or status", args[0])
+	}
+}
+
+func runAuthLogin(args []string) error {
+	fs := flag.NewFlagSet("auth login", flag.ExitOnError)
+	fprovider := fs.String("provider", "", "Git provider to authenticate with: one of github or gitlab.")
+	fhostname := fs.String("hostname", "", "Host to authenticate with. (default github.com or gitlab.com)")
+	fclientID := fs.String("client-id", os.Getenv("PIPESCOPE_OAUTH_CLIENT_ID"), "OAuth application client ID supporting the device flow (env=PIPESCOPE_OAUTH_CLIENT_ID).")
+	fscopes := fs.String("scopes", "", "Comma-separated OAuth scopes to request. (default repo for github, read_api for gitlab)")
+	fconfigPath := fs.String("config", os.Getenv("PIPESCOPE_CONFIG"), "Path to the pipescope config file (env=PIPESCOPE_CONFIG).")
+
+	fs.Parse(args) //nolint:errcheck
+
+	var (
+		endpoint func(string) auth.Endpoint
+		host     = *fhostname
+		scopes   = *fscopes
+	)
+
+	switch gateway.ProviderType(*fprovider) {
+	case gateway.GitHub:
+		endpoint, host, scopes = auth.GitHubEndpoint, withDefault(host, "github.com"), withDefault(scopes, "repo")
+	case gateway.GitLab:
+		endpoint, host, scopes = auth.GitLabEndpoint, withDefault(host, "gitlab.com"), withDefault(scopes, "read_api")
+	default:
+		return fmt.Errorf("unknown provider %q: --provider must be one of %s or %s", *fprovider, gateway.GitHub, gateway.GitLab)
+	}
+
+	clientID := *fclientID
+	if clientID == "" {
+		// Hosts are only configured by the user's config file, not the repository's
+		cfg, err := config.LoadGlobal(withDefault(*fconfigPath, defaultConfigPath()))
+		if err != nil {
+			return err
+		}
+
+		hostCfg, _ := cfg.Host(host)
+		clientID = hostCfg.OAuthClientID
+	}
+
+	if clientID == "" {
+		return fmt.Errorf("an OAuth application client ID is required: pass --client-id or set hosts.%s.oauth_client_id in the config file", host)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	flow := auth.NewDeviceFlow(clientID, strings.Split(scopes, ","), endpoint(host))
+
+	code, err := flow.Start(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "First copy your one-time code: %s\nThen open %s in your browser to authorize pipescope.\n", code.UserCode, code.VerificationURI)
+	fmt.Fprintln(os.Stderr, "Waiting for authorization...")
+
+	token, err := flow.Wait(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	path, err := auth.DefaultStorePath()
+	if err != nil {
+		return err
+	}
+
+	err = auth.NewStore(path).Save(host, auth.Credential{
+		Provider:     *fprovider,
+		Token:        token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Scopes:       token.Scopes,
+		ExpiresAt:    token.ExpiresAt,
+		CreatedAt:    time.Now().UTC(),
+		ClientID:     clientID,
+		TokenURL:     flow.Endpoint.TokenURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Logged in to %s. Credentials stored in %s\n", host, path)
+
+	return nil
+}
+
+func runAuthStatus(args []string) error {
+	fs := flag.NewFlagSet("auth status", flag.ExitOnError)
+	fs.Parse(args) //nolint:errcheck
+
+	path, err := auth.DefaultStorePath()
+	if err != nil {
+		return err
+	}
+
+	store := auth.NewStore(path)
+
+	creds, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	hosts, err := store.Hosts()
+	if err != nil {
+		return err
+	}
+
+	if len(hosts) == 0 {
+		fmt.Fprintln(os.Stderr, "Not logged in to any hosts. Run `pipescope auth login --provider github|gitlab` to log in.")
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tPROVIDER\tTOKEN\tSCOPES\tEXPIRES")
+
+	for _, host := range hosts {
+		cred := creds[host]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", host, cred.Provider, maskToken(cred.Token), withDefault(strings.Join(cred.Scopes, ","), "-"), expiry(cred))
+	}
+
+	return w.Flush() //nolint:wrapcheck
+}
+
+func expiry(cred auth.Credential) string {
+	switch {
+	case cred.ExpiresAt.IsZero():
+		return "never"
+	case cred.Expired(time.Now()) && cred.RefreshToken != "":
+		return fmt.Sprintf("%s (expired, will refresh)", cred.ExpiresAt.Local().Format(time.DateTime))
+	case cred.Expired(time.Now()):
+		return fmt.Sprintf("%s (expired)", cred.ExpiresAt.Local().Format(time.DateTime))
+	default:
+		return cred.ExpiresAt.Local().Format(time.DateTime)
+	}
+}
+
+// maskToken hides all but a token's prefix, which identifies its type.
+func maskToken(token string) string {
+	const visible = 4
+	if len(token) <= visible {
+		return strings.Repeat("*", len(token))
+	}
+
+	return token[:visible] + strings.Repeat("*", 8)
+}
+
+func withDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
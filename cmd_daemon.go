@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/daemon"
+	"github.com/gregfurman/pipescope/internal/metrics"
+)
+
+// runDaemon watches the latest pipelines of many repositories, serving their state over HTTP.
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	opts := newOptions(fs)
+	faddr := fs.String("addr", "", "Address to serve the HTTP API on. (default \"localhost:7878\")")
+	finterval := fs.Duration("interval", 30*time.Second, "How often watched refs are checked for new pipelines.")
+
+	var watches []config.Watch
+	fs.Func("watch", "Repository remote URL to watch, optionally followed by @ref. May be repeated.", func(s string) error {
+		watches = append(watches, parseWatch(s))
+		return nil
+	})
+
+	fs.Parse(args) //nolint:errcheck
+
+	cfg, err := config.Load(opts.configPath, opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	opts.applySettings(cfg.Resolve("", ""))
+
+	if err := opts.setupLogger(); err != nil {
+		return err
+	}
+
+	// The daemon always serves metrics alongside its API, and on -metrics-addr too if set
+	opts.metrics = metrics.New()
+	opts.serveMetrics()
+
+	notifiers, err := opts.notifiers()
+	if err != nil {
+		return err
+	}
+
+	if len(watches) == 0 {
+		watches = cfg.Daemon.Watch
+	}
+
+	if len(watches) == 0 {
+		return errors.New("no repositories to watch: pass --watch or configure daemon.watch in the config file")
+	}
+
+	targets := make([]daemon.Target, 0, len(watches))
+	for _, w := range watches {
+		client, err := opts.newGatewayClient(cfg, w.Repo)
+		if err != nil {
+			return fmt.Errorf("failed to create client for %s: %w", w.Repo, err)
+		}
+
+		svc := opts.newService(client, nil)
+		targets = append(targets, daemon.Target{Watch: w, Service: svc, Schedule: opts.scheduler(svc)})
+	}
+
+	d := daemon.New(*finterval)
+	d.OnEvent = func(event checker.Event) {
+		report(pipelineLogger(event.Pipeline), event, notifiers)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", d.Handler())
+	mux.Handle("/metrics", opts.metrics)
+
+	addr := withDefault(*faddr, withDefault(cfg.Daemon.Addr, "localhost:7878"))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background()) //nolint:errcheck
+	}()
+
+	go d.Run(ctx, targets)
+
+	slog.Info(fmt.Sprintf("Serving daemon API [addr=%s, watches=%d]", addr, len(targets)))
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("daemon server failed: %w", err)
+	}
+
+	return nil
+}
+
+// parseWatch parses a "remote[@ref]" watch. An @ is only taken to start the ref if it follows the
+// remote's path, so scp-like remotes such as git@github.com:owner/repo are left intact.
+func parseWatch(s string) config.Watch {
+	at := strings.LastIndex(s, "@")
+	if at == -1 || at < strings.LastIndex(s, "/") {
+		return config.Watch{Repo: s}
+	}
+
+	return config.Watch{Repo: s[:at], Ref: s[at+1:]}
+}
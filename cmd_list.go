@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+	"github.com/gregfurman/pipescope/internal/git"
+)
+
+// listedPipeline is a pipeline as written by `pipescope list -output json`.
+type listedPipeline struct {
+	ID              int       `json:"id"`
+	SHA             string    `json:"sha"`
+	Ref             string    `json:"ref"`
+	Status          string    `json:"status"`
+	Author          string    `json:"author,omitempty"`
+	DurationSeconds int       `json:"duration_seconds"`
+	CreatedAt       time.Time `json:"created_at"`
+	URL             string    `json:"url"`
+}
+
+// runList prints the most recent pipelines of the repository, optionally filtered by ref and status.
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	opts := newOptions(fs)
+	frepo := fs.String("repo", "", "Remote URL of the repository to list pipelines of. (default the git repository's origin)")
+	fref := fs.String("ref", "", "Only list pipelines of this branch or tag.")
+	fstatus := fs.String("status", "", "Only list pipelines with this status, e.g. failed, success or running.")
+	flimit := fs.Int("limit", 20, "Most pipelines to list.")
+
+	fs.Parse(args) //nolint:errcheck
+
+	url := *frepo
+	if url == "" {
+		gitClient, err := git.New(opts.gitDirectory)
+		if err != nil {
+			return err
+		}
+
+		if url, err = gitClient.GetRemoteURL(); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(opts.configPath, opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	opts.applySettings(cfg.Resolve(gateway.ParseRemoteURL(url)))
+
+	if err := opts.setupLogger(); err != nil {
+		return err
+	}
+
+	client, err := opts.newGatewayClient(cfg, url)
+	if err != nil {
+		return err
+	}
+
+	pipelines, err := client.ListPipelines(url, gateway.ListOptions{
+		Ref:     *fref,
+		Status:  *fstatus,
+		Limit:   *flimit,
+		Details: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.output == outputJSON {
+		listed := make([]listedPipeline, 0, len(pipelines))
+		for _, p := range pipelines {
+			listed = append(listed, listedPipeline{
+				ID:              p.ID,
+				SHA:             p.CommitSha,
+				Ref:             p.Ref,
+				Status:          p.Status,
+				Author:          p.Author,
+				DurationSeconds: int(p.Duration().Seconds()),
+				CreatedAt:       p.CreatedAt,
+				URL:             p.URL,
+			})
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(listed) //nolint:wrapcheck
+	}
+
+	if len(pipelines) == 0 {
+		fmt.Fprintln(os.Stderr, "No pipelines found.")
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSHA\tREF\tSTATUS\tAUTHOR\tDURATION\tURL")
+
+	for _, p := range pipelines {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, shortSHA(p.CommitSha), p.Ref, p.Status, withDefault(p.Author, "-"), duration(p), p.URL)
+	}
+
+	return w.Flush() //nolint:wrapcheck
+}
+
+func shortSHA(sha string) string {
+	const length = 8
+	if len(sha) <= length {
+		return sha
+	}
+
+	return sha[:length]
+}
+
+func duration(p gateway.Pipeline) string {
+	d := p.Duration()
+	if d == 0 {
+		return "-"
+	}
+
+	return d.Round(time.Second).String()
+}
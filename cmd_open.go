@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+	"github.com/gregfurman/pipescope/internal/git"
+)
+
+// runOpen opens the HEAD pipeline, one of its jobs or the merge request of the HEAD commit in the
+// system browser.
+func runOpen(args []string) error {
+	fs := flag.NewFlagSet("open", flag.ExitOnError)
+	opts := newOptions(fs)
+	fjob := fs.String("job", "", "Open the pipeline's job with this name rather than the pipeline.")
+	fmr := fs.Bool("mr", false, "Open the merge or pull request of the HEAD commit rather than the pipeline.")
+	fprint := fs.Bool("print", false, "Print the URL rather than opening it.")
+
+	fs.Parse(args) //nolint:errcheck
+
+	gitClient, err := git.New(opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	url, err := gitClient.GetRemoteURL()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(opts.configPath, opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	opts.applySettings(cfg.Resolve(gateway.ParseRemoteURL(url)))
+
+	if err := opts.setupLogger(); err != nil {
+		return err
+	}
+
+	client, err := opts.newGatewayClient(cfg, url)
+	if err != nil {
+		return err
+	}
+
+	var target string
+
+	if *fmr {
+		target, err = mergeRequestURL(client, gitClient, url)
+	} else {
+		target, err = pipelineURL(checker.New(client, gitClient), client, *fjob)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if *fprint {
+		fmt.Println(target)
+
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Opening %s\n", target)
+
+	return openBrowser(target)
+}
+
+// pipelineURL returns the URL of the HEAD pipeline, or of its job with the given name.
+func pipelineURL(svc *checker.Service, client gateway.Client, jobName string) (string, error) {
+	pipeline, err := svc.GetPipeline()
+	if err != nil {
+		return "", err
+	}
+
+	if jobName == "" {
+		return pipeline.URL, nil
+	}
+
+	jobs, err := client.GetPipelineJobs(pipeline.ProjectID, pipeline.ID)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Name == jobName {
+			return job.URL, nil
+		}
+
+		names = append(names, job.Name)
+	}
+
+	return "", fmt.Errorf("pipeline %d has no job named %q: expected one of %s", pipeline.ID, jobName, strings.Join(names, ", "))
+}
+
+// mergeRequestURL returns the URL of the merge request containing the HEAD commit, preferring open ones.
+func mergeRequestURL(client gateway.Client, gitClient git.Client, url string) (string, error) {
+	finder, ok := client.(gateway.MergeRequestFinder)
+	if !ok {
+		return "", errors.New("finding merge requests is not supported by this provider")
+	}
+
+	sha, err := gitClient.GetHead()
+	if err != nil {
+		return "", err
+	}
+
+	mrs, err := finder.MergeRequestsByCommit(url, sha)
+	if err != nil {
+		return "", err
+	}
+
+	if len(mrs) == 0 {
+		return "", fmt.Errorf("no merge requests found for commit %s", shortSHA(sha))
+	}
+
+	return mrs[0].URL, nil
+}
+
+// openBrowser opens a URL with $BROWSER if set, or otherwise the system's default browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+
+	switch {
+	case os.Getenv("BROWSER") != "":
+		cmd = exec.Command(os.Getenv("BROWSER"), url)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", url)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w: pass -print to print the URL instead", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+	"github.com/gregfurman/pipescope/internal/git"
+	"github.com/gregfurman/pipescope/internal/prompt"
+)
+
+// runPrompt prints a glyph for the status of the HEAD pipeline, for use in shell prompts and status
+// bars. The status is read from an on-disk cache, which is refreshed in the background once stale
+// so that rendering a prompt never waits on the provider for longer than -timeout.
+func runPrompt(args []string) error {
+	fs := flag.NewFlagSet("prompt", flag.ExitOnError)
+	opts := newOptions(fs)
+	ftimeout := fs.Duration("timeout", 150*time.Millisecond, "Longest time to wait for a stale status to be refreshed before printing the cached one.")
+	fttl := fs.Duration("ttl", 15*time.Second, "How long the status of a pending pipeline is cached before being refreshed.")
+	frefresh := fs.Bool("refresh", false, "Refresh the cached status in the foreground rather than printing it.")
+
+	fs.Parse(args) //nolint:errcheck
+
+	// Outside of a repository with a remote there is nothing to show
+	gitClient, err := git.New(opts.gitDirectory)
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+
+	url, err := gitClient.GetRemoteURL()
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+
+	sha, err := gitClient.GetHead()
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+
+	dir, err := prompt.DefaultDir()
+	if err != nil {
+		return err
+	}
+
+	cache := prompt.NewCache(dir)
+	key := prompt.Key(url, sha)
+
+	if *frefresh {
+		defer cache.Unlock(key)
+
+		return cache.Store(key, opts.promptEntry(gitClient, url))
+	}
+
+	entry, ok := cache.Load(key)
+	if !ok || !entry.Fresh(time.Now(), *fttl) {
+		if refreshed, done := refreshPrompt(cache, key, args, *ftimeout); done {
+			entry, ok = refreshed, true
+		}
+	}
+
+	if ok {
+		fmt.Print(entry.State.Glyph())
+	}
+
+	return nil
+}
+
+// refreshPrompt starts a background refresh of a cached status unless one is already running,
+// returning the refreshed entry if it completes within the timeout. A refresh that takes longer
+// carries on after pipescope exits, ready for the next prompt.
+func refreshPrompt(cache *prompt.Cache, key string, args []string, timeout time.Duration) (prompt.Entry, bool) {
+	if !cache.Lock(key) {
+		return prompt.Entry{}, false
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		cache.Unlock(key)
+
+		return prompt.Entry{}, false
+	}
+
+	// The refresh's output is discarded, so command substitutions needn't wait for it to exit
+	cmd := exec.Command(exe, append([]string{"prompt", "-refresh"}, args...)...)
+	if err := cmd.Start(); err != nil {
+		cache.Unlock(key)
+
+		return prompt.Entry{}, false
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case <-done:
+		return cache.Load(key)
+	case <-time.After(timeout):
+		return prompt.Entry{}, false
+	}
+}
+
+// promptEntry retrieves the status of the HEAD pipeline, recording any failure to do so.
+func (o *options) promptEntry(gitClient git.Client, url string) prompt.Entry {
+	entry := prompt.Entry{State: prompt.StateError}
+
+	pipeline, client, err := o.headPipeline(gitClient, url)
+
+	switch {
+	case errors.Is(err, gateway.ErrNoPipeline):
+		entry.State = prompt.StateNone
+	case err != nil:
+		entry.Error = err.Error()
+	case client.IsStatusPending(pipeline.Status):
+		entry.State = prompt.StatePending
+	case client.IsStatusFailed(pipeline.Status):
+		entry.State = prompt.StateFailed
+	default:
+		entry.State = prompt.StateSuccess
+	}
+
+	if pipeline != nil {
+		entry.Status, entry.URL = pipeline.Status, pipeline.URL
+	}
+
+	entry.CheckedAt = time.Now()
+
+	return entry
+}
+
+// headPipeline fetches the HEAD pipeline with only the user's global config applied, as prompts
+// refresh in the background of any repository changed into.
+func (o *options) headPipeline(gitClient git.Client, url string) (*gateway.Pipeline, gateway.Client, error) {
+	cfg, err := config.LoadGlobal(o.configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o.applySettings(cfg.Resolve(gateway.ParseRemoteURL(url)))
+
+	client, err := o.newGatewayClient(cfg, url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pipeline, err := checker.New(client, gitClient).GetPipeline()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pipeline, client, nil
+}
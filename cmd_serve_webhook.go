@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/metrics"
+	"github.com/gregfurman/pipescope/internal/webhook"
+)
+
+// runServeWebhook receives pipeline events from GitLab and GitHub webhooks rather than polling,
+// reporting them through the same notifiers.
+func runServeWebhook(args []string) error {
+	fs := flag.NewFlagSet("serve-webhook", flag.ExitOnError)
+	opts := newOptions(fs)
+	faddr := fs.String("addr", "127.0.0.1:8080", "Address to listen for webhook deliveries on. Addresses other than loopback require -secret.")
+	fsecret := fs.String("secret", os.Getenv("PIPESCOPE_WEBHOOK_SECRET"), "Secret used to sign GitHub deliveries and sent as GitLab's X-Gitlab-Token (env=PIPESCOPE_WEBHOOK_SECRET).")
+
+	fs.Parse(args) //nolint:errcheck
+
+	cfg, err := config.Load(opts.configPath, opts.gitDirectory)
+	if err != nil {
+		return err
+	}
+
+	opts.applySettings(cfg.Resolve("", ""))
+
+	if err := opts.setupLogger(); err != nil {
+		return err
+	}
+
+	if opts.metricsAddr != "" {
+		opts.metrics = metrics.New()
+		opts.serveMetrics()
+	}
+
+	notifiers, err := opts.notifiers()
+	if err != nil {
+		return err
+	}
+
+	if *fsecret == "" {
+		// Unverified deliveries could otherwise be forged by anyone able to reach the address
+		if !isLoopback(*faddr) {
+			return fmt.Errorf("refusing to listen on %s without a webhook secret: set -secret or listen on a loopback address", *faddr)
+		}
+
+		slog.Warn("no webhook secret configured: deliveries will not be verified")
+	}
+
+	receiver := webhook.NewReceiver(*fsecret)
+	server := &http.Server{
+		Addr:              *faddr,
+		Handler:           receiver,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background()) //nolint:errcheck
+	}()
+
+	go func() {
+		for event := range receiver.Events() {
+			report(pipelineLogger(event.Pipeline), event, notifiers)
+		}
+	}()
+
+	slog.Info(fmt.Sprintf("Listening for webhooks [addr=%s]", *faddr))
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("webhook server failed: %w", err)
+	}
+
+	return nil
+}
+
+// isLoopback reports whether a listen address only accepts connections from the local host.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
@@ -8,6 +8,7 @@ require (
 	github.com/go-git/go-git/v5 v5.12.0
 	github.com/google/go-github/v61 v61.0.0
 	github.com/xanzy/go-gitlab v0.102.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
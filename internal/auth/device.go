@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	grantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeRefreshToken = "refresh_token"
+
+	defaultPollInterval = 5 * time.Second
+
+	// slowDownIncrement is added to the polling interval whenever the server asks us to slow down (RFC 8628 §3.5).
+	slowDownIncrement = 5 * time.Second
+)
+
+var (
+	ErrAccessDenied = errors.New("authorization was denied")
+	ErrExpired      = errors.New("device code expired before authorization was completed")
+)
+
+// Endpoint holds the OAuth device authorization and token URLs of a provider.
+type Endpoint struct {
+	DeviceAuthURL string
+	TokenURL      string
+}
+
+func GitHubEndpoint(host string) Endpoint {
+	return Endpoint{
+		DeviceAuthURL: fmt.Sprintf("https://%s/login/device/code", host),
+		TokenURL:      fmt.Sprintf("https://%s/login/oauth/access_token", host),
+	}
+}
+
+func GitLabEndpoint(host string) Endpoint {
+	return Endpoint{
+		DeviceAuthURL: fmt.Sprintf("https://%s/oauth/authorize_device", host),
+		TokenURL:      fmt.Sprintf("https://%s/oauth/token", host),
+	}
+}
+
+// DeviceFlow performs the OAuth 2.0 device authorization grant (RFC 8628).
+type DeviceFlow struct {
+	ClientID string
+	Scopes   []string
+	Endpoint Endpoint
+
+	client *http.Client
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration) error
+}
+
+func NewDeviceFlow(clientID string, scopes []string, endpoint Endpoint) *DeviceFlow {
+	return &DeviceFlow{
+		ClientID: clientID,
+		Scopes:   scopes,
+		Endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Scopes       []string
+
+	// ExpiresAt is zero for tokens that do not expire.
+	ExpiresAt time.Time
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	Scope            string `json:"scope"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Start requests a device and user code. The user must visit the verification URI and enter
+// the user code before Wait returns a token.
+func (f *DeviceFlow) Start(ctx context.Context) (*DeviceCode, error) {
+	var resp struct {
+		DeviceCode
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	form := url.Values{
+		"client_id": {f.ClientID},
+		"scope":     {strings.Join(f.Scopes, " ")},
+	}
+
+	if err := f.post(ctx, f.Endpoint.DeviceAuthURL, form, &resp); err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("failed to request device code: %s: %s", resp.Error, resp.ErrorDescription)
+	}
+
+	if resp.DeviceCode.DeviceCode == "" {
+		return nil, errors.New("failed to request device code: no device code returned")
+	}
+
+	return &resp.DeviceCode, nil
+}
+
+// Wait polls the token endpoint until the user authorizes the device, denies it, or the code expires.
+func (f *DeviceFlow) Wait(ctx context.Context, code *DeviceCode) (*Token, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	deadline := f.now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	form := url.Values{
+		"client_id":   {f.ClientID},
+		"device_code": {code.DeviceCode},
+		"grant_type":  {grantTypeDeviceCode},
+	}
+
+	for {
+		if code.ExpiresIn > 0 && f.now().After(deadline) {
+			return nil, ErrExpired
+		}
+
+		if err := f.sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+
+		var resp tokenResponse
+		if err := f.post(ctx, f.Endpoint.TokenURL, form, &resp); err != nil {
+			return nil, fmt.Errorf("failed to request access token: %w", err)
+		}
+
+		switch resp.Error {
+		case "":
+			return f.token(resp), nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownIncrement
+		case "access_denied":
+			return nil, ErrAccessDenied
+		case "expired_token":
+			return nil, ErrExpired
+		default:
+			return nil, fmt.Errorf("failed to request access token: %s: %s", resp.Error, resp.ErrorDescription)
+		}
+	}
+}
+
+// Refresh exchanges a refresh token for a new access token.
+func Refresh(ctx context.Context, clientID, tokenURL, refreshToken string) (*Token, error) {
+	f := NewDeviceFlow(clientID, nil, Endpoint{TokenURL: tokenURL})
+
+	form := url.Values{
+		"client_id":     {clientID},
+		"refresh_token": {refreshToken},
+		"grant_type":    {grantTypeRefreshToken},
+	}
+
+	var resp tokenResponse
+	if err := f.post(ctx, tokenURL, form, &resp); err != nil {
+		return nil, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("failed to refresh access token: %s: %s", resp.Error, resp.ErrorDescription)
+	}
+
+	return f.token(resp), nil
+}
+
+func (f *DeviceFlow) token(resp tokenResponse) *Token {
+	token := &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		Scopes:       strings.FieldsFunc(resp.Scope, func(r rune) bool { return r == ' ' || r == ',' }),
+	}
+
+	if resp.ExpiresIn > 0 {
+		token.ExpiresAt = f.now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	return token
+}
+
+func (f *DeviceFlow) post(ctx context.Context, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub responds with a form encoded body unless JSON is explicitly requested
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Pending authorizations are reported as 400s with an error code in the body
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unexpected %s response from %s: %s", resp.Status, endpoint, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newDeviceServer stands in for a provider's OAuth endpoints, responding to token polls with
+// the given sequence of errors before issuing a token.
+func newDeviceServer(t *testing.T, pollErrors ...string) *httptest.Server {
+	t.Helper()
+
+	polls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") != "CLIENT_ID" || r.FormValue("scope") != "repo read:org" {
+			t.Errorf("unexpected device code request %v", r.Form)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"device_code":      "DEVICE_CODE",
+			"user_code":        "ABCD-1234",
+			"verification_uri": "https://github.com/login/device",
+			"expires_in":       900,
+			"interval":         5,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("device_code") != "DEVICE_CODE" || r.FormValue("grant_type") != grantTypeDeviceCode {
+			t.Errorf("unexpected token request %v", r.Form)
+		}
+
+		if polls < len(pollErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": pollErrors[polls]})
+			polls++
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "gho_token",
+			"refresh_token": "ghr_refresh",
+			"scope":         "repo,read:org",
+			"expires_in":    28800,
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func Test_DeviceFlow(t *testing.T) {
+	now := time.Date(2024, 4, 18, 17, 55, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		pollErrors []string
+
+		want         *Token
+		wantErr      error
+		wantInterval time.Duration
+	}{
+		{
+			name:         "Polls until authorized",
+			pollErrors:   []string{"authorization_pending", "slow_down"},
+			want:         &Token{AccessToken: "gho_token", RefreshToken: "ghr_refresh", Scopes: []string{"repo", "read:org"}, ExpiresAt: now.Add(8 * time.Hour)},
+			wantInterval: 10 * time.Second,
+		},
+		{
+			name:       "Fails when denied",
+			pollErrors: []string{"authorization_pending", "access_denied"},
+			wantErr:    ErrAccessDenied,
+		},
+		{
+			name:       "Fails when expired",
+			pollErrors: []string{"expired_token"},
+			wantErr:    ErrExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			server := newDeviceServer(ts, tt.pollErrors...)
+
+			var lastInterval time.Duration
+
+			f := NewDeviceFlow("CLIENT_ID", []string{"repo", "read:org"}, Endpoint{DeviceAuthURL: server.URL + "/device", TokenURL: server.URL + "/token"})
+			f.now = func() time.Time { return now }
+			f.sleep = func(_ context.Context, d time.Duration) error {
+				lastInterval = d
+				return nil
+			}
+
+			code, err := f.Start(context.Background())
+			if err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if code.UserCode != "ABCD-1234" {
+				ts.Errorf("expected user code ABCD-1234, got %s", code.UserCode)
+			}
+
+			got, err := f.Wait(context.Background(), code)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					ts.Errorf("expected error %s, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+			if lastInterval != tt.wantInterval {
+				ts.Errorf("expected polling interval to be slowed to %s, got %s", tt.wantInterval, lastInterval)
+			}
+		})
+	}
+}
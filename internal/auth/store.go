@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const storeFileName = "credentials.yaml"
+
+// refreshWindow is how long before expiry a stored token is refreshed.
+const refreshWindow = time.Minute
+
+// Credential is a token obtained through `pipescope auth login`.
+type Credential struct {
+	Provider     string    `yaml:"provider"`
+	Token        string    `yaml:"token"`
+	RefreshToken string    `yaml:"refresh_token,omitempty"`
+	Scopes       []string  `yaml:"scopes,omitempty"`
+	ExpiresAt    time.Time `yaml:"expires_at,omitempty"`
+	CreatedAt    time.Time `yaml:"created_at"`
+
+	// ClientID and TokenURL are kept so that the token can be refreshed.
+	ClientID string `yaml:"client_id"`
+	TokenURL string `yaml:"token_url"`
+}
+
+// Expired reports whether the credential has expired. Credentials without an expiry never do.
+func (c Credential) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt)
+}
+
+// Store persists credentials per host in a file only readable by the current user.
+type Store struct {
+	path string
+	now  func() time.Time
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path, now: time.Now}
+}
+
+// DefaultStorePath returns the location of the credentials file alongside pipescope's config.
+func DefaultStorePath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pipescope", storeFileName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "pipescope", storeFileName), nil
+}
+
+// Load returns every stored credential, keyed by host.
+func (s *Store) Load() (map[string]Credential, error) {
+	creds := make(map[string]Credential)
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return creds, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	if err := yaml.Unmarshal(b, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", s.path, err)
+	}
+
+	return creds, nil
+}
+
+// Hosts returns the hosts with stored credentials in alphabetical order.
+func (s *Store) Hosts() ([]string, error) {
+	creds, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, 0, len(creds))
+	for host := range creds {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+
+	return hosts, nil
+}
+
+// Save stores a host's credential, replacing any existing one.
+func (s *Store) Save(host string, cred Credential) error {
+	creds, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	creds[host] = cred
+
+	b, err := yaml.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+
+	// Write to a temporary file first so that a failed write never truncates existing credentials
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), storeFileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to restrict credentials file permissions: %w", err)
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	return nil
+}
+
+// Token returns a usable access token for the host, refreshing and re-storing it if it is
+// about to expire. Expired credentials that cannot be refreshed are ignored.
+func (s *Store) Token(ctx context.Context, host string) (string, bool, error) {
+	creds, err := s.Load()
+	if err != nil {
+		return "", false, err
+	}
+
+	cred, ok := creds[host]
+	if !ok {
+		return "", false, nil
+	}
+
+	if !cred.Expired(s.now().Add(refreshWindow)) {
+		return cred.Token, true, nil
+	}
+
+	if cred.RefreshToken == "" || cred.TokenURL == "" {
+		return "", false, nil
+	}
+
+	token, err := Refresh(ctx, cred.ClientID, cred.TokenURL, cred.RefreshToken)
+	if err != nil {
+		return "", false, err
+	}
+
+	cred.Token, cred.ExpiresAt = token.AccessToken, token.ExpiresAt
+	if token.RefreshToken != "" {
+		cred.RefreshToken = token.RefreshToken
+	}
+
+	if len(token.Scopes) > 0 {
+		cred.Scopes = token.Scopes
+	}
+
+	if err := s.Save(host, cred); err != nil {
+		return "", false, err
+	}
+
+	return cred.Token, true, nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func Test_Store_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipescope", "credentials.yaml")
+	store := NewStore(path)
+
+	want := Credential{Provider: "github", Token: "gho_token", Scopes: []string{"repo"}, CreatedAt: time.Date(2024, 4, 18, 17, 55, 0, 0, time.UTC)}
+
+	if err := store.Save("github.com", want); err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if err := store.Save("gitlab.com", Credential{Provider: "gitlab", Token: "gitlab-token"}); err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected credentials file to exist: %s", err)
+		}
+
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("expected credentials file to be private, got %o", perm)
+		}
+	}
+
+	hosts, err := store.Hosts()
+	if err != nil || len(hosts) != 2 || hosts[0] != "github.com" || hosts[1] != "gitlab.com" {
+		t.Errorf("expected [github.com gitlab.com], got %v (err=%v)", hosts, err)
+	}
+
+	token, ok, err := store.Token(context.Background(), "github.com")
+	if err != nil || !ok || token != want.Token {
+		t.Errorf("expected %s, got %s (ok=%v, err=%v)", want.Token, token, ok, err)
+	}
+}
+
+func Test_Store_TokenRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != grantTypeRefreshToken || r.FormValue("refresh_token") != "old-refresh" {
+			t.Errorf("unexpected refresh request %v", r.Form)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "new-token", "refresh_token": "new-refresh", "expires_in": 7200})
+	}))
+	defer server.Close()
+
+	store := NewStore(filepath.Join(t.TempDir(), "credentials.yaml"))
+
+	err := store.Save("gitlab.com", Credential{
+		Provider:     "gitlab",
+		Token:        "old-token",
+		RefreshToken: "old-refresh",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+		ClientID:     "CLIENT_ID",
+		TokenURL:     server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	token, ok, err := store.Token(context.Background(), "gitlab.com")
+	if err != nil || !ok || token != "new-token" {
+		t.Fatalf("expected refreshed token, got %s (ok=%v, err=%v)", token, ok, err)
+	}
+
+	creds, _ := store.Load()
+	if cred := creds["gitlab.com"]; cred.RefreshToken != "new-refresh" || cred.Expired(time.Now()) {
+		t.Errorf("expected refreshed credential to be stored, got %+v", cred)
+	}
+}
@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"github.com/gregfurman/pipescope/internal/excerpt"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+type EventType string
+
+const (
+	// EventStatusChanged is emitted when a pipeline is first observed and whenever its status changes.
+	EventStatusChanged EventType = "status_changed"
+
+	// EventCompleted is emitted once a pipeline reaches a terminal status.
+	EventCompleted EventType = "completed"
+
+	// EventDownstreamChanged is emitted when a downstream pipeline is first observed, and whenever
+	// its status changes, while awaiting the downstream pipelines of a completed pipeline.
+	EventDownstreamChanged EventType = "downstream_changed"
+
+	// EventError is emitted when the pipeline could no longer be retrieved.
+	EventError EventType = "error"
+)
+
+type Event struct {
+	Type           EventType
+	Pipeline       *gateway.Pipeline
+	PreviousStatus string
+
+	// Bridge names what triggered the downstream pipeline of EventDownstreamChanged events.
+	Bridge string
+
+	// Failed reports whether the pipeline's status is considered a failure by its provider.
+	Failed bool
+
+	// Succeeded reports whether the pipeline, and any downstream pipelines, succeeded. Pipelines
+	// that were canceled, skipped or are awaiting manual action neither failed nor succeeded.
+	Succeeded bool
+
+	// FailedJobs is only populated for EventCompleted events, and includes the failed jobs of
+	// failed downstream pipelines.
+	FailedJobs []gateway.Job
+
+	// Jobs holds every job of the pipeline for EventCompleted events, when they were fetched.
+	Jobs []gateway.Job
+
+	// Downstream holds the pipelines triggered by the pipeline for EventCompleted events, which
+	// have all completed too.
+	Downstream []gateway.PipelineTree
+
+	// TestReport holds the failed tests of failed pipelines for EventCompleted events, where the
+	// provider reports them.
+	TestReport *gateway.TestReport
+
+	// Coverage holds the pipeline's code coverage for EventCompleted events, where the provider
+	// reports it.
+	Coverage *CoverageReport
+
+	// Excerpts holds the errors extracted from the logs of failed jobs for EventCompleted events,
+	// when log excerpts are enabled.
+	Excerpts []JobExcerpt
+
+	Err error
+}
+
+// JobExcerpt is the part of a failed job's log that explains its failure.
+type JobExcerpt struct {
+	Job     gateway.Job
+	Excerpt excerpt.Excerpt
+}
+
+// CoverageReport compares a pipeline's code coverage with that of its base branch.
+type CoverageReport struct {
+	gateway.Coverage
+
+	// BaseRef is the branch compared against: the merge request's target branch or the project's
+	// default branch.
+	BaseRef string
+
+	// Base is the coverage of the base branch's latest successful pipeline, or nil if unknown.
+	Base *gateway.Coverage
+}
+
+// Delta returns the change in coverage from the base branch, in percentage points, if known.
+func (r *CoverageReport) Delta() (float64, bool) {
+	if r.Base == nil {
+		return 0, false
+	}
+
+	return r.Percent - r.Base.Percent, true
+}
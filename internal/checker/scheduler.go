@@ -0,0 +1,113 @@
+package checker
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+// historySize is the number of recent pipelines on a ref used to estimate a pipeline's duration.
+const historySize = 10
+
+// Scheduler decides how long to wait before polling a pipeline again.
+type Scheduler interface {
+	Next(pipeline *gateway.Pipeline) time.Duration
+}
+
+// FixedScheduler polls at a constant frequency.
+type FixedScheduler time.Duration
+
+func (f FixedScheduler) Next(*gateway.Pipeline) time.Duration {
+	return time.Duration(f)
+}
+
+// AdaptiveScheduler polls rapidly while a pipeline is starting up and as it approaches its expected
+// duration, backing off through the middle of long pipelines and once they overrun.
+type AdaptiveScheduler struct {
+	min, max time.Duration
+	expected time.Duration
+	now      func() time.Time
+}
+
+// NewAdaptiveScheduler creates a scheduler polling between the given intervals. An expected
+// duration of zero means none is known, in which case polling backs off steadily as it runs.
+func NewAdaptiveScheduler(minInterval, maxInterval, expected time.Duration) *AdaptiveScheduler {
+	return &AdaptiveScheduler{
+		min:      minInterval,
+		max:      max(minInterval, maxInterval),
+		expected: expected,
+		now:      time.Now,
+	}
+}
+
+func (a *AdaptiveScheduler) Next(pipeline *gateway.Pipeline) time.Duration {
+	if pipeline.StartedAt.IsZero() {
+		return a.min
+	}
+
+	elapsed := a.now().Sub(pipeline.StartedAt)
+
+	switch {
+	case a.expected == 0:
+		return a.clamp(elapsed / 10)
+	case elapsed < a.expected/10:
+		return a.min
+	case elapsed < a.expected:
+		// Halving the remaining time converges on the expected finish without overshooting it
+		return a.clamp((a.expected - elapsed) / 2)
+	default:
+		return a.clamp((elapsed - a.expected) / 4)
+	}
+}
+
+func (a *AdaptiveScheduler) clamp(d time.Duration) time.Duration {
+	return min(max(d, a.min), a.max)
+}
+
+// AdaptiveScheduler creates a scheduler expecting the pipeline to take as long as recent pipelines
+// on its ref. If these cannot be listed, no duration is expected.
+func (s *Service) AdaptiveScheduler(pipeline *gateway.Pipeline, minInterval, maxInterval time.Duration) *AdaptiveScheduler {
+	expected, err := s.ExpectedDuration(pipeline)
+	if err != nil {
+		slog.Debug("could not estimate pipeline duration", slog.Any("error", err))
+	}
+
+	return NewAdaptiveScheduler(minInterval, maxInterval, expected)
+}
+
+// ExpectedDuration returns the median duration of recent pipelines on the same ref as the given
+// pipeline that succeeded, or zero if there are none. Canceled and skipped pipelines stop early,
+// so would otherwise shorten the estimate.
+func (s *Service) ExpectedDuration(pipeline *gateway.Pipeline) (time.Duration, error) {
+	// Only successful pipelines are listed, so a run of failures doesn't leave nothing to estimate from
+	pipelines, err := s.gatewayClient.ListPipelines(pipeline.ProjectID, gateway.ListOptions{
+		Ref:    pipeline.Ref,
+		Status: statusSuccess,
+		Limit:  historySize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var durations []time.Duration
+
+	for _, p := range pipelines {
+		if p.ID == pipeline.ID || p.FinishedAt.IsZero() || p.Status != statusSuccess {
+			continue
+		}
+
+		if d := p.Duration(); d > 0 {
+			durations = append(durations, d)
+		}
+	}
+
+	if len(durations) == 0 {
+		return 0, nil
+	}
+
+	slices.Sort(durations)
+
+	return durations[len(durations)/2], nil
+}
@@ -0,0 +1,84 @@
+package checker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+func Test_AdaptiveScheduler_Next(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expected time.Duration
+		elapsed  time.Duration
+		pending  bool
+
+		want time.Duration
+	}{
+		{name: "Pipeline yet to start", expected: 10 * time.Minute, pending: true, want: 5 * time.Second},
+		{name: "Pipeline just started", expected: 10 * time.Minute, elapsed: 30 * time.Second, want: 5 * time.Second},
+		{name: "Middle of a long pipeline backs off", expected: 10 * time.Minute, elapsed: 5 * time.Minute, want: time.Minute},
+		{name: "Nearing the expected duration", expected: 10 * time.Minute, elapsed: 9*time.Minute + 30*time.Second, want: 15 * time.Second},
+		{name: "Overrunning pipeline backs off again", expected: 10 * time.Minute, elapsed: 12 * time.Minute, want: 30 * time.Second},
+		{name: "No history backs off as the pipeline runs", elapsed: 5 * time.Minute, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			scheduler := NewAdaptiveScheduler(5*time.Second, time.Minute, tt.expected)
+			scheduler.now = func() time.Time { return now }
+
+			pipeline := &gateway.Pipeline{}
+			if !tt.pending {
+				pipeline.StartedAt = now.Add(-tt.elapsed)
+			}
+
+			if got := scheduler.Next(pipeline); got != tt.want {
+				ts.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_Service_ExpectedDuration(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	finished := func(id int, status string, d time.Duration) gateway.Pipeline {
+		return gateway.Pipeline{ID: id, Ref: "main", Status: status, StartedAt: start, FinishedAt: start.Add(d)}
+	}
+
+	var gotOpts gateway.ListOptions
+	providerClient := &providerMock{
+		mockedListPipelines: func(id string, opts gateway.ListOptions) ([]gateway.Pipeline, error) {
+			gotOpts = opts
+			return []gateway.Pipeline{
+				{ID: 5, Ref: "main", Status: "running", StartedAt: start},
+				finished(4, "success", 4*time.Minute),
+				finished(3, "failed", time.Minute),
+				finished(6, "canceled", 10*time.Second),
+				finished(7, "skipped", 30*time.Second),
+				finished(2, "success", 6*time.Minute),
+				finished(1, "success", 5*time.Minute),
+			}, nil
+		},
+		mockedIsStatusPending: func(status string) bool { return status == "running" },
+		mockedIsStatusFailed:  func(status string) bool { return status == "failed" },
+	}
+
+	svc := New(providerClient, &gitMock{})
+
+	got, err := svc.ExpectedDuration(&gateway.Pipeline{ID: 5, ProjectID: "PROJECT_ID", Ref: "main"})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got != 5*time.Minute {
+		t.Errorf("expected median duration of 5m0s, got %s", got)
+	}
+
+	if gotOpts.Ref != "main" || gotOpts.Status != "success" || gotOpts.Limit != historySize {
+		t.Errorf("expected successful pipelines on main to be listed, got %v", gotOpts)
+	}
+}
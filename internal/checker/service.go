@@ -1,25 +1,82 @@
 package checker
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/gregfurman/pipescope/internal/excerpt"
 	"github.com/gregfurman/pipescope/internal/gateway"
 	"github.com/gregfurman/pipescope/internal/git"
 )
 
+// statusSuccess is the status of successful pipelines on both GitLab and GitHub.
+const statusSuccess = "success"
+
+// defaultDownstreamTimeout is how long the downstream pipelines of a completed pipeline are awaited
+// by default.
+const defaultDownstreamTimeout = time.Hour
+
+// maxExcerptJobs is the most failed jobs whose logs are fetched for excerpts per pipeline.
+const maxExcerptJobs = 5
+
 type Service struct {
 	gatewayClient gateway.Client
 	gitClient     git.Client
+
+	// allJobs fetches the jobs of every completed pipeline, not only failed ones.
+	allJobs bool
+
+	// coverageBase is the branch coverage is compared against, the default branch if empty.
+	coverageBase string
+
+	// downstreamTimeout bounds how long downstream pipelines are awaited once a pipeline completes.
+	downstreamTimeout time.Duration
+
+	// excerpts extracts the errors from the logs of failed jobs, if set.
+	excerpts *excerpt.Registry
 }
 
 func New(gw gateway.Client, gc git.Client) *Service {
 	return &Service{
-		gatewayClient: gw,
-		gitClient:     gc,
+		gatewayClient:     gw,
+		gitClient:         gc,
+		downstreamTimeout: defaultDownstreamTimeout,
 	}
 }
 
+// WithAllJobs makes completed events carry every job of the pipeline, whatever its outcome.
+func (s *Service) WithAllJobs() *Service {
+	s.allJobs = true
+
+	return s
+}
+
+// WithCoverageBase compares the coverage of completed pipelines against a branch other than the
+// default branch, such as a merge request's target branch.
+func (s *Service) WithCoverageBase(ref string) *Service {
+	s.coverageBase = ref
+
+	return s
+}
+
+// WithDownstreamTimeout bounds how long the downstream pipelines of a completed pipeline are
+// awaited, after which the pipeline is reported with its downstream pipelines still pending.
+func (s *Service) WithDownstreamTimeout(d time.Duration) *Service {
+	s.downstreamTimeout = d
+
+	return s
+}
+
+// WithLogExcerpts makes completed events of failed pipelines carry excerpts of their failed jobs'
+// logs, extracted by the registry.
+func (s *Service) WithLogExcerpts(registry *excerpt.Registry) *Service {
+	s.excerpts = registry
+
+	return s
+}
+
 func (s *Service) GetPipelineStatus() (string, error) {
 	pipeline, err := s.GetPipeline()
 	if err != nil {
@@ -52,6 +109,77 @@ func (s *Service) GetPipeline() (*gateway.Pipeline, error) {
 	return pipeline, nil
 }
 
+// GetMergeRequestPipeline returns the open merge request of the checked out branch along with its
+// latest pipeline, which may differ from the branch pipeline of the same commit.
+func (s *Service) GetMergeRequestPipeline() (*gateway.MergeRequest, *gateway.Pipeline, error) {
+	watcher, ok := s.gatewayClient.(gateway.MergeRequestWatcher)
+	if !ok {
+		return nil, nil, errors.New("watching merge requests is not supported by this provider")
+	}
+
+	url, err := s.gitClient.GetRemoteURL()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get remote url from git: %w", err)
+	}
+
+	branch, err := s.gitClient.GetBranch()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get branch from git: %w", err)
+	}
+
+	mr, err := watcher.OpenMergeRequest(url, branch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get merge request from Gateway client: %w", err)
+	}
+
+	pipeline, err := watcher.MergeRequestPipeline(url, mr)
+	if err != nil {
+		return mr, nil, fmt.Errorf("failed to get merge request pipeline from Gateway client: %w", err)
+	}
+
+	return mr, pipeline, nil
+}
+
+// MergeStatus returns whether a merge request of the repository can be merged.
+func (s *Service) MergeStatus(mr *gateway.MergeRequest) (*gateway.MergeStatus, error) {
+	watcher, ok := s.gatewayClient.(gateway.MergeRequestWatcher)
+	if !ok {
+		return nil, errors.New("watching merge requests is not supported by this provider")
+	}
+
+	url, err := s.gitClient.GetRemoteURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote url from git: %w", err)
+	}
+
+	status, err := watcher.MergeStatus(url, mr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge status from Gateway client: %w", err)
+	}
+
+	return status, nil
+}
+
+// LatestPipeline returns the most recent pipeline of a project on a ref, or nil if there are none.
+// An empty ref matches pipelines on any ref.
+func (s *Service) LatestPipeline(id, ref string) (*gateway.Pipeline, error) {
+	pipelines, err := s.gatewayClient.ListPipelines(id, gateway.ListOptions{Ref: ref, Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipelines from Gateway client: %w", err)
+	}
+
+	if len(pipelines) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &pipelines[0], nil
+}
+
+// IsPending reports whether a pipeline is yet to complete.
+func (s *Service) IsPending(pipeline *gateway.Pipeline) bool {
+	return s.gatewayClient.IsStatusPending(pipeline.Status)
+}
+
 func (s *Service) GetPipelineStatusByID(id string, pid int) (string, error) {
 	pipeline, err := s.gatewayClient.GetPipeline(id, pid)
 	if err != nil {
@@ -88,3 +216,296 @@ func (s *Service) PollPipelineStatus(id string, pid int, freq time.Duration) (ch
 
 	return statusCh, doneCh
 }
+
+// WatchPipeline polls a pipeline as often as the scheduler decides until it is no longer pending,
+// emitting an Event for its initial status, for every status change, and a final EventCompleted
+// (or EventError) before the channel is closed. Where the provider supports downstream pipelines,
+// completion is only reported once every downstream pipeline has completed too.
+func (s *Service) WatchPipeline(pipeline *gateway.Pipeline, scheduler Scheduler) <-chan Event {
+	eventCh := make(chan Event)
+
+	go func() {
+		defer close(eventCh)
+
+		eventCh <- s.newEvent(EventStatusChanged, pipeline, "")
+
+		current := pipeline
+		for s.gatewayClient.IsStatusPending(current.Status) {
+			time.Sleep(s.pollInterval(scheduler.Next(current)))
+
+			next, err := s.gatewayClient.GetPipeline(current.ProjectID, current.ID)
+			if err != nil {
+				eventCh <- Event{Type: EventError, Pipeline: current, Err: fmt.Errorf("failed to poll pipeline: %w", err)}
+
+				return
+			}
+
+			if next.Status != current.Status {
+				eventCh <- s.newEvent(EventStatusChanged, next, current.Status)
+			}
+
+			current = next
+		}
+
+		eventCh <- s.completedEvent(current, s.awaitDownstream(current, scheduler, eventCh))
+	}()
+
+	return eventCh
+}
+
+// pollInterval stretches the poll frequency when the provider's API quota is running low.
+func (s *Service) pollInterval(freq time.Duration) time.Duration {
+	limiter, ok := s.gatewayClient.(gateway.RateLimiter)
+	if !ok {
+		return freq
+	}
+
+	limit, ok := limiter.RateLimit()
+	if !ok {
+		return freq
+	}
+
+	interval := limit.Interval(freq, time.Now())
+
+	slog.Debug("API rate limit budget",
+		slog.Int("remaining", limit.Remaining),
+		slog.Int("limit", limit.Limit),
+		slog.Time("reset", limit.Reset),
+		slog.Duration("poll_interval", interval),
+	)
+
+	return interval
+}
+
+func (s *Service) newEvent(t EventType, pipeline *gateway.Pipeline, previous string) Event {
+	return Event{
+		Type:           t,
+		Pipeline:       pipeline,
+		PreviousStatus: previous,
+		Failed:         s.gatewayClient.IsStatusFailed(pipeline.Status),
+		Succeeded:      pipeline.Status == statusSuccess,
+	}
+}
+
+// awaitDownstream polls the downstream pipelines of a completed pipeline until they have all
+// completed or the downstream timeout elapses, emitting an event whenever one changes status.
+// Downstream pipelines are best-effort, so nil is returned if they cannot be retrieved.
+func (s *Service) awaitDownstream(pipeline *gateway.Pipeline, scheduler Scheduler, eventCh chan<- Event) *gateway.PipelineTree {
+	finder, ok := s.gatewayClient.(gateway.DownstreamFinder)
+	if !ok {
+		return nil
+	}
+
+	// Pipelines are told apart by their bridge too, as GitHub's placeholders for runs yet to start
+	// have no ID
+	type downstreamKey struct {
+		projectID string
+		id        int
+		bridge    string
+	}
+
+	deadline := time.Now().Add(s.downstreamTimeout)
+	statuses := make(map[downstreamKey]string)
+
+	for {
+		tree, err := finder.PipelineTree(pipeline.ProjectID, pipeline.ID)
+		if err != nil {
+			slog.Warn("failed to retrieve downstream pipelines", slog.Any("error", err))
+
+			return nil
+		}
+
+		tree.Walk(func(child *gateway.PipelineTree) {
+			key := downstreamKey{projectID: child.Pipeline.ProjectID, id: child.Pipeline.ID, bridge: child.Bridge}
+
+			if previous, seen := statuses[key]; !seen || previous != child.Pipeline.Status {
+				downstream := child.Pipeline
+
+				event := s.newEvent(EventDownstreamChanged, &downstream, previous)
+				event.Bridge = child.Bridge
+				eventCh <- event
+
+				statuses[key] = child.Pipeline.Status
+			}
+		})
+
+		pending := tree.Pending(s.gatewayClient.IsStatusPending)
+		if pending == nil {
+			return tree
+		}
+
+		if !time.Now().Before(deadline) {
+			slog.Warn("stopped waiting for downstream pipelines", slog.Duration("timeout", s.downstreamTimeout), slog.Int("downstream_pipeline_id", pending.ID), slog.String("status", pending.Status))
+
+			return tree
+		}
+
+		slog.Debug("Waiting for downstream pipeline", slog.Int("downstream_pipeline_id", pending.ID), slog.String("status", pending.Status))
+		time.Sleep(min(s.pollInterval(scheduler.Next(pending)), max(time.Until(deadline), 0)))
+	}
+}
+
+func (s *Service) completedEvent(pipeline *gateway.Pipeline, tree *gateway.PipelineTree) Event {
+	event := s.newEvent(EventCompleted, pipeline, "")
+
+	// A parent pipeline may succeed regardless of its downstream pipelines, unless its bridges
+	// mirror their status
+	var failedDownstream []*gateway.Pipeline
+
+	if tree != nil {
+		event.Downstream = tree.Downstream
+
+		tree.Walk(func(child *gateway.PipelineTree) {
+			if s.gatewayClient.IsStatusFailed(child.Pipeline.Status) {
+				failedDownstream = append(failedDownstream, &child.Pipeline)
+			}
+		})
+	}
+
+	event.Failed = event.Failed || len(failedDownstream) > 0
+	event.Succeeded = event.Succeeded && !event.Failed
+	event.Coverage = s.coverageReport(pipeline)
+
+	if !event.Failed && !s.allJobs {
+		return event
+	}
+
+	if event.Failed {
+		event.TestReport = s.testReport(pipeline)
+	}
+
+	jobs, err := s.gatewayClient.GetPipelineJobs(pipeline.ProjectID, pipeline.ID)
+	if err != nil {
+		// Job details are best-effort: the pipeline's outcome is still worth reporting.
+		return event
+	}
+
+	event.Jobs = jobs
+	if s.gatewayClient.IsStatusFailed(pipeline.Status) {
+		event.FailedJobs = s.failedJobs(jobs)
+		event.Excerpts = s.jobExcerpts(pipeline.ProjectID, event.FailedJobs, maxExcerptJobs)
+	}
+
+	for _, downstream := range failedDownstream {
+		jobs, err := s.gatewayClient.GetPipelineJobs(downstream.ProjectID, downstream.ID)
+		if err != nil {
+			continue
+		}
+
+		failed := s.failedJobs(jobs)
+		event.FailedJobs = append(event.FailedJobs, failed...)
+		event.Excerpts = append(event.Excerpts, s.jobExcerpts(downstream.ProjectID, failed, maxExcerptJobs-len(event.Excerpts))...)
+	}
+
+	return event
+}
+
+// coverageReport returns the pipeline's coverage compared against its base branch, or nil if it
+// reports none. Coverage is best-effort, so errors are only logged.
+func (s *Service) coverageReport(pipeline *gateway.Pipeline) *CoverageReport {
+	reporter, ok := s.gatewayClient.(gateway.CoverageReporter)
+	if !ok {
+		return nil
+	}
+
+	coverage, err := reporter.Coverage(pipeline.ProjectID, pipeline.ID)
+	if err != nil {
+		slog.Warn("failed to retrieve coverage", slog.Any("error", err))
+
+		return nil
+	} else if coverage == nil {
+		return nil
+	}
+
+	report := &CoverageReport{Coverage: *coverage, BaseRef: s.coverageBase}
+
+	if report.BaseRef == "" {
+		if report.BaseRef, err = reporter.DefaultBranch(pipeline.ProjectID); err != nil {
+			slog.Warn("failed to retrieve default branch", slog.Any("error", err))
+
+			return report
+		}
+	}
+
+	// The latest successful pipeline of the base branch may be the watched pipeline itself
+	pipelines, err := s.gatewayClient.ListPipelines(pipeline.ProjectID, gateway.ListOptions{Ref: report.BaseRef, Status: "success", Limit: 2})
+	if err != nil {
+		slog.Warn("failed to list base branch pipelines", slog.Any("error", err))
+
+		return report
+	}
+
+	for _, base := range pipelines {
+		if base.ID == pipeline.ID {
+			continue
+		}
+
+		if report.Base, err = reporter.Coverage(base.ProjectID, base.ID); err != nil {
+			slog.Warn("failed to retrieve base branch coverage", slog.Any("error", err))
+		}
+
+		break
+	}
+
+	return report
+}
+
+// testReport returns the pipeline's test report, or nil if it cannot be retrieved as test results
+// are best-effort.
+func (s *Service) testReport(pipeline *gateway.Pipeline) *gateway.TestReport {
+	reporter, ok := s.gatewayClient.(gateway.TestReporter)
+	if !ok {
+		return nil
+	}
+
+	report, err := reporter.TestReport(pipeline.ProjectID, pipeline.ID)
+	if err != nil {
+		slog.Warn("failed to retrieve test report", slog.Any("error", err))
+
+		return nil
+	}
+
+	return report
+}
+
+// jobExcerpts returns excerpts of the logs of up to limit jobs. Logs are best-effort, so jobs whose
+// logs cannot be retrieved, or have nothing to excerpt, are skipped.
+func (s *Service) jobExcerpts(projectID string, jobs []gateway.Job, limit int) []JobExcerpt {
+	reader, ok := s.gatewayClient.(gateway.JobLogReader)
+	if !ok || s.excerpts == nil {
+		return nil
+	}
+
+	var excerpts []JobExcerpt
+
+	for _, job := range jobs {
+		if len(excerpts) >= limit {
+			break
+		}
+
+		log, err := reader.JobLog(projectID, job.ID)
+		if err != nil {
+			slog.Warn("failed to retrieve job log", slog.String("job", job.Name), slog.Any("error", err))
+
+			continue
+		}
+
+		if extracted := s.excerpts.Extract(log); len(extracted.Lines) > 0 {
+			excerpts = append(excerpts, JobExcerpt{Job: job, Excerpt: extracted})
+		}
+	}
+
+	return excerpts
+}
+
+func (s *Service) failedJobs(jobs []gateway.Job) []gateway.Job {
+	var failedJobs []gateway.Job
+
+	for _, job := range jobs {
+		if s.gatewayClient.IsStatusFailed(job.Status) {
+			failedJobs = append(failedJobs, job)
+		}
+	}
+
+	return failedJobs
+}
@@ -1,17 +1,23 @@
 package checker
 
 import (
+	"errors"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gregfurman/pipescope/internal/excerpt"
 	"github.com/gregfurman/pipescope/internal/gateway"
 )
 
 type providerMock struct {
 	mockedGetPipelineBySha func(id, sha string) (*gateway.Pipeline, error)
 	mockedGetPipeline      func(id string, pid int) (*gateway.Pipeline, error)
+	mockedGetPipelineJobs  func(id string, pid int) ([]gateway.Job, error)
+	mockedListPipelines    func(id string, opts gateway.ListOptions) ([]gateway.Pipeline, error)
 	mockedIsStatusPending  func(status string) bool
+	mockedIsStatusFailed   func(status string) bool
 	lock                   sync.Mutex
 }
 
@@ -27,6 +33,18 @@ func (pm *providerMock) GetPipeline(id string, pid int) (*gateway.Pipeline, erro
 	return pipeline, err
 }
 
+func (pm *providerMock) GetPipelineJobs(id string, pid int) ([]gateway.Job, error) {
+	return pm.mockedGetPipelineJobs(id, pid)
+}
+
+func (pm *providerMock) ListPipelines(id string, opts gateway.ListOptions) ([]gateway.Pipeline, error) {
+	return pm.mockedListPipelines(id, opts)
+}
+
+func (pm *providerMock) IsStatusFailed(status string) bool {
+	return pm.mockedIsStatusFailed(status)
+}
+
 func (pm *providerMock) IsStatusPending(status string) bool {
 	pm.lock.Lock()
 	flag := pm.mockedIsStatusPending(status)
@@ -36,12 +54,16 @@ func (pm *providerMock) IsStatusPending(status string) bool {
 
 type gitMock struct {
 	mockedGetHead      func() (string, error)
+	mockedGetBranch    func() (string, error)
 	mockedGetRemoteURL func() (string, error)
 }
 
 func (gm *gitMock) GetHead() (string, error) {
 	return gm.mockedGetHead()
 }
+func (gm *gitMock) GetBranch() (string, error) {
+	return gm.mockedGetBranch()
+}
 func (gm *gitMock) GetRemoteURL() (string, error) {
 	return gm.mockedGetRemoteURL()
 }
@@ -119,3 +141,350 @@ func Test_Service(t *testing.T) {
 	}
 
 }
+
+func Test_Service_WatchPipeline(t *testing.T) {
+	pending := gateway.Pipeline{ID: 1, ProjectID: "PROJECT_ID", Status: "running"}
+	failed := gateway.Pipeline{ID: 1, ProjectID: "PROJECT_ID", Status: "failed"}
+
+	polls := 0
+	providerClient := &providerMock{
+		mockedGetPipeline: func(id string, pid int) (*gateway.Pipeline, error) {
+			polls++
+			if polls < 2 {
+				return &pending, nil
+			}
+			return &failed, nil
+		},
+		mockedGetPipelineJobs: func(id string, pid int) ([]gateway.Job, error) {
+			return []gateway.Job{{ID: 1, Name: "build", Status: "success"}, {ID: 2, Name: "test", Status: "failed"}}, nil
+		},
+		mockedIsStatusPending: func(status string) bool { return status == "running" },
+		mockedIsStatusFailed:  func(status string) bool { return status == "failed" },
+	}
+
+	svc := New(providerClient, &gitMock{})
+
+	var events []Event
+	for event := range svc.WatchPipeline(&pending, FixedScheduler(10*time.Millisecond)) {
+		events = append(events, event)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %v", len(events), events)
+	}
+
+	if events[0].Type != EventStatusChanged || events[0].Pipeline.Status != "running" {
+		t.Errorf("expected initial running status event, got %v", events[0])
+	}
+
+	if events[1].Type != EventStatusChanged || events[1].PreviousStatus != "running" || events[1].Pipeline.Status != "failed" {
+		t.Errorf("expected running to failed status event, got %v", events[1])
+	}
+
+	completed := events[2]
+	if completed.Type != EventCompleted || !completed.Failed {
+		t.Errorf("expected failed completion event, got %v", completed)
+	}
+
+	if len(completed.FailedJobs) != 1 || completed.FailedJobs[0].Name != "test" {
+		t.Errorf("expected failed job 'test', got %v", completed.FailedJobs)
+	}
+}
+
+type watcherMock struct {
+	providerMock
+	mergeRequests map[string]gateway.MergeRequest
+	pipelines     map[int]gateway.Pipeline
+}
+
+func (wm *watcherMock) MergeRequestsByCommit(id, sha string) ([]gateway.MergeRequest, error) {
+	return nil, nil
+}
+
+func (wm *watcherMock) OpenMergeRequest(id, branch string) (*gateway.MergeRequest, error) {
+	mr, ok := wm.mergeRequests[branch]
+	if !ok {
+		return nil, gateway.ErrNoMergeRequest
+	}
+
+	return &mr, nil
+}
+
+func (wm *watcherMock) MergeRequestPipeline(id string, mr *gateway.MergeRequest) (*gateway.Pipeline, error) {
+	pipeline, ok := wm.pipelines[mr.ID]
+	if !ok {
+		return nil, gateway.ErrNoPipeline
+	}
+
+	return &pipeline, nil
+}
+
+func (wm *watcherMock) MergeStatus(id string, mr *gateway.MergeRequest) (*gateway.MergeStatus, error) {
+	return &gateway.MergeStatus{Mergeable: true, Detail: "mergeable"}, nil
+}
+
+func Test_Service_GetMergeRequestPipeline(ts *testing.T) {
+	client := &watcherMock{
+		mergeRequests: map[string]gateway.MergeRequest{
+			"feature": {ID: 7, SourceBranch: "feature", TargetBranch: "main"},
+			"stale":   {ID: 8, SourceBranch: "stale", TargetBranch: "main"},
+		},
+		pipelines: map[int]gateway.Pipeline{
+			7: {ID: 70, ProjectID: "PROJECT_ID", Status: "running"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		branch      string
+		expectedMR  int
+		expectedID  int
+		expectedErr error
+	}{
+		{name: "merge request pipeline", branch: "feature", expectedMR: 7, expectedID: 70},
+		{name: "no merge request", branch: "main", expectedErr: gateway.ErrNoMergeRequest},
+		{name: "no pipeline", branch: "stale", expectedMR: 8, expectedErr: gateway.ErrNoPipeline},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			svc := New(client, &gitMock{
+				mockedGetBranch:    func() (string, error) { return test.branch, nil },
+				mockedGetRemoteURL: func() (string, error) { return "www.example.com/repo/owner", nil },
+			})
+
+			mr, pipeline, err := svc.GetMergeRequestPipeline()
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+
+			if mr != nil && mr.ID != test.expectedMR {
+				t.Errorf("expected merge request %d, got %d", test.expectedMR, mr.ID)
+			}
+
+			if pipeline != nil && pipeline.ID != test.expectedID {
+				t.Errorf("expected pipeline %d, got %d", test.expectedID, pipeline.ID)
+			}
+		})
+	}
+}
+
+type downstreamMock struct {
+	providerMock
+	trees []gateway.PipelineTree
+}
+
+func (dm *downstreamMock) PipelineTree(id string, pid int) (*gateway.PipelineTree, error) {
+	tree := dm.trees[0]
+	if len(dm.trees) > 1 {
+		dm.trees = dm.trees[1:]
+	}
+
+	return &tree, nil
+}
+
+func Test_Service_WatchPipeline_Downstream(t *testing.T) {
+	parent := gateway.Pipeline{ID: 1, ProjectID: "1", Status: "success"}
+	running := gateway.PipelineTree{Pipeline: gateway.Pipeline{ID: 2, ProjectID: "1", Status: "running"}, Bridge: "child"}
+	failed := gateway.PipelineTree{Pipeline: gateway.Pipeline{ID: 2, ProjectID: "1", Status: "failed"}, Bridge: "child"}
+
+	client := &downstreamMock{
+		providerMock: providerMock{
+			mockedGetPipelineJobs: func(id string, pid int) ([]gateway.Job, error) {
+				if pid == 2 {
+					return []gateway.Job{{ID: 20, Name: "child-test", Status: "failed"}}, nil
+				}
+
+				return []gateway.Job{{ID: 10, Name: "build", Status: "success"}}, nil
+			},
+			mockedIsStatusPending: func(status string) bool { return status == "running" },
+			mockedIsStatusFailed:  func(status string) bool { return status == "failed" },
+		},
+		trees: []gateway.PipelineTree{
+			{Pipeline: parent, Downstream: []gateway.PipelineTree{running}},
+			{Pipeline: parent, Downstream: []gateway.PipelineTree{failed}},
+		},
+	}
+
+	svc := New(client, &gitMock{})
+
+	var (
+		completed  Event
+		downstream []string
+	)
+
+	for event := range svc.WatchPipeline(&parent, FixedScheduler(time.Millisecond)) {
+		if event.Type == EventDownstreamChanged {
+			downstream = append(downstream, event.Bridge+":"+event.PreviousStatus+"->"+event.Pipeline.Status)
+		}
+
+		completed = event
+	}
+
+	if completed.Type != EventCompleted || !completed.Failed {
+		t.Fatalf("expected failed completion event, got %v", completed)
+	}
+
+	if want := []string{"child:->running", "child:running->failed"}; !reflect.DeepEqual(want, downstream) {
+		t.Errorf("expected downstream events %v, got %v", want, downstream)
+	}
+
+	if len(completed.Downstream) != 1 || completed.Downstream[0].Pipeline.Status != "failed" {
+		t.Errorf("expected completed downstream pipeline, got %v", completed.Downstream)
+	}
+
+	if len(completed.FailedJobs) != 1 || completed.FailedJobs[0].Name != "child-test" {
+		t.Errorf("expected failed downstream job 'child-test', got %v", completed.FailedJobs)
+	}
+}
+
+func Test_Service_WatchPipeline_DownstreamTimeout(t *testing.T) {
+	parent := gateway.Pipeline{ID: 1, ProjectID: "1", Status: "success"}
+	stuck := gateway.PipelineTree{Pipeline: gateway.Pipeline{ID: 2, ProjectID: "1", Status: "created"}, Bridge: "deploy"}
+
+	client := &downstreamMock{
+		providerMock: providerMock{
+			mockedIsStatusPending: func(status string) bool { return status == "created" },
+			mockedIsStatusFailed:  func(status string) bool { return status == "failed" },
+		},
+		trees: []gateway.PipelineTree{{Pipeline: parent, Downstream: []gateway.PipelineTree{stuck}}},
+	}
+
+	svc := New(client, &gitMock{}).WithDownstreamTimeout(20 * time.Millisecond)
+
+	done := make(chan Event)
+
+	go func() {
+		var completed Event
+		for event := range svc.WatchPipeline(&parent, FixedScheduler(time.Millisecond)) {
+			completed = event
+		}
+
+		done <- completed
+	}()
+
+	select {
+	case completed := <-done:
+		if completed.Type != EventCompleted || completed.Failed {
+			t.Errorf("expected successful completion event, got %v", completed)
+		}
+
+		if len(completed.Downstream) != 1 || completed.Downstream[0].Pipeline.Status != "created" {
+			t.Errorf("expected pending downstream pipeline, got %v", completed.Downstream)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the wait for downstream pipelines to time out")
+	}
+}
+
+type coverageMock struct {
+	providerMock
+	coverage map[int]*gateway.Coverage
+}
+
+func (cm *coverageMock) Coverage(id string, pid int) (*gateway.Coverage, error) {
+	return cm.coverage[pid], nil
+}
+
+func (cm *coverageMock) DefaultBranch(id string) (string, error) {
+	return "main", nil
+}
+
+func Test_Service_CoverageReport(ts *testing.T) {
+	tests := []struct {
+		name          string
+		baseRef       string
+		basePipelines []gateway.Pipeline
+		expectedRef   string
+		expectedDelta float64
+		expectedKnown bool
+	}{
+		{name: "Compared against default branch", basePipelines: []gateway.Pipeline{{ID: 5}}, expectedRef: "main", expectedDelta: -2.5, expectedKnown: true},
+		{name: "Compared against target branch", baseRef: "release", basePipelines: []gateway.Pipeline{{ID: 5}}, expectedRef: "release", expectedDelta: -2.5, expectedKnown: true},
+		{name: "Watched pipeline is skipped", basePipelines: []gateway.Pipeline{{ID: 9}, {ID: 5}}, expectedRef: "main", expectedDelta: -2.5, expectedKnown: true},
+		{name: "No base pipeline", expectedRef: "main"},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			var listedRef string
+
+			client := &coverageMock{
+				providerMock: providerMock{
+					mockedListPipelines: func(id string, opts gateway.ListOptions) ([]gateway.Pipeline, error) {
+						listedRef = opts.Ref
+
+						return test.basePipelines, nil
+					},
+					mockedIsStatusFailed: func(status string) bool { return false },
+				},
+				coverage: map[int]*gateway.Coverage{9: {Percent: 80}, 5: {Percent: 82.5}},
+			}
+
+			svc := New(client, &gitMock{}).WithCoverageBase(test.baseRef)
+
+			event := svc.completedEvent(&gateway.Pipeline{ID: 9, Status: "success"}, nil)
+			if event.Coverage == nil {
+				t.Fatal("expected a coverage report, got nil")
+			}
+
+			if event.Coverage.BaseRef != test.expectedRef || listedRef != test.expectedRef {
+				t.Errorf("expected base ref %s, got %s (listed %s)", test.expectedRef, event.Coverage.BaseRef, listedRef)
+			}
+
+			delta, known := event.Coverage.Delta()
+			if delta != test.expectedDelta || known != test.expectedKnown {
+				t.Errorf("expected delta %v (%t), got %v (%t)", test.expectedDelta, test.expectedKnown, delta, known)
+			}
+		})
+	}
+}
+
+type jobLogMock struct {
+	providerMock
+	logs map[int]string
+}
+
+func (lm *jobLogMock) JobLog(id string, jobID int) (string, error) {
+	log, ok := lm.logs[jobID]
+	if !ok {
+		return "", errors.New("log not found")
+	}
+
+	return log, nil
+}
+
+func Test_Service_JobExcerpts(t *testing.T) {
+	client := &jobLogMock{
+		providerMock: providerMock{
+			mockedGetPipelineJobs: func(id string, pid int) ([]gateway.Job, error) {
+				return []gateway.Job{
+					{ID: 1, Name: "build", Status: "success"},
+					{ID: 2, Name: "test", Status: "failed"},
+					{ID: 3, Name: "lint", Status: "failed"},
+					{ID: 4, Name: "deploy", Status: "failed"},
+				}, nil
+			},
+			mockedIsStatusFailed: func(status string) bool { return status == "failed" },
+		},
+		logs: map[int]string{
+			1: "ok\n",
+			2: "--- FAIL: TestParse (0.00s)\n    parse_test.go:42: expected 2 keys, got 1\nFAIL\n",
+			3: "",
+		},
+	}
+
+	svc := New(client, &gitMock{}).WithLogExcerpts(excerpt.Default(10))
+
+	event := svc.completedEvent(&gateway.Pipeline{ID: 9, Status: "failed"}, nil)
+
+	// Jobs with empty or missing logs are skipped
+	if len(event.Excerpts) != 1 {
+		t.Fatalf("expected 1 excerpt, got %v", event.Excerpts)
+	}
+
+	got := event.Excerpts[0]
+	if got.Job.Name != "test" || got.Excerpt.Extractor != "go test" || len(got.Excerpt.Lines) != 2 {
+		t.Errorf("expected a 'go test' excerpt of job 'test', got %v", got)
+	}
+}
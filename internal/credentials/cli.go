@@ -0,0 +1,56 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ghHosts mirrors gh's hosts.yml. Recent versions of gh keep tokens in the OS keyring,
+// in which case no oauth_token is present.
+type ghHosts map[string]struct {
+	OAuthToken string `yaml:"oauth_token"`
+}
+
+// glabConfig mirrors the hosts section of glab's config.yml.
+type glabConfig struct {
+	Hosts map[string]struct {
+		Token string `yaml:"token"`
+	} `yaml:"hosts"`
+}
+
+func (r *Resolver) fromGitHubCLI(host, provider string) string {
+	if provider != providerGitHub {
+		return ""
+	}
+
+	var hosts ghHosts
+	if !readYAML(filepath.Join(r.configDir("GH_CONFIG_DIR", "gh"), "hosts.yml"), &hosts) {
+		return ""
+	}
+
+	return hosts[host].OAuthToken
+}
+
+func (r *Resolver) fromGitLabCLI(host, provider string) string {
+	if provider != providerGitLab {
+		return ""
+	}
+
+	var cfg glabConfig
+	if !readYAML(filepath.Join(r.configDir("GLAB_CONFIG_DIR", "glab-cli"), "config.yml"), &cfg) {
+		return ""
+	}
+
+	return cfg.Hosts[host].Token
+}
+
+func readYAML(path string, v any) bool {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	return yaml.Unmarshal(b, v) == nil
+}
@@ -0,0 +1,185 @@
+package credentials
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gregfurman/pipescope/internal/auth"
+)
+
+const (
+	providerGitHub = "github"
+	providerGitLab = "gitlab"
+)
+
+type Source string
+
+const (
+	SourceEnv           Source = "env"
+	SourceCIJobToken    Source = "ci-job-token"
+	SourceLogin         Source = "pipescope"
+	SourceGitHubCLI     Source = "gh"
+	SourceGitLabCLI     Source = "glab"
+	SourceNetrc         Source = "netrc"
+	SourceGitCredential Source = "git-credential"
+)
+
+type Credential struct {
+	Token  string
+	Source Source
+}
+
+// Resolver discovers API tokens for a git host from the credentials of other tools.
+type Resolver struct {
+	getenv        func(string) string
+	homeDir       string
+	store         *auth.Store
+	gitCredential func(host string) (string, error)
+}
+
+func NewResolver() *Resolver {
+	home, _ := os.UserHomeDir()
+
+	r := &Resolver{
+		getenv:        os.Getenv,
+		homeDir:       home,
+		gitCredential: gitCredentialFill,
+	}
+
+	if path, err := auth.DefaultStorePath(); err == nil {
+		r.store = auth.NewStore(path)
+	}
+
+	return r
+}
+
+// Lookup discovers a token for a host using a new Resolver.
+func Lookup(host, provider string) (Credential, bool) {
+	return NewResolver().Lookup(host, provider)
+}
+
+// Lookup returns the first token found for a host, trying in order: provider environment
+// variables, tokens stored by `pipescope auth login`, the gh and glab CLI configs, ~/.netrc
+// and finally `git credential fill`. The
+// provider may be empty, in which case it is inferred from the host's name.
+func (r *Resolver) Lookup(host, provider string) (Credential, bool) {
+	if host == "" {
+		return Credential{}, false
+	}
+
+	if provider == "" {
+		provider = inferProvider(host)
+	}
+
+	lookups := []struct {
+		source Source
+		lookup func(host, provider string) string
+	}{
+		{SourceEnv, r.fromEnv},
+		{SourceCIJobToken, r.fromCIJobToken},
+		{SourceLogin, r.fromLogin},
+		{SourceGitHubCLI, r.fromGitHubCLI},
+		{SourceGitLabCLI, r.fromGitLabCLI},
+		{SourceNetrc, r.fromNetrc},
+		{SourceGitCredential, r.fromGitCredential},
+	}
+
+	for _, l := range lookups {
+		if token := l.lookup(host, provider); token != "" {
+			slog.Debug("discovered access token", slog.String("host", host), slog.String("source", string(l.source)))
+
+			return Credential{Token: token, Source: l.source}, true
+		}
+	}
+
+	return Credential{}, false
+}
+
+func (r *Resolver) fromEnv(host, provider string) string {
+	var names []string
+
+	switch provider {
+	case providerGitHub:
+		names = []string{"GH_TOKEN", "GITHUB_TOKEN"}
+		if host != "github.com" {
+			names = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+		}
+	case providerGitLab:
+		names = []string{"GITLAB_TOKEN"}
+	}
+
+	for _, name := range names {
+		if v := r.getenv(name); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// fromCIJobToken returns the job token of a GitLab CI/CD job, which is only valid against the
+// instance running the job.
+func (r *Resolver) fromCIJobToken(host, provider string) string {
+	if provider != providerGitLab || host != r.getenv("CI_SERVER_HOST") {
+		return ""
+	}
+
+	return r.getenv("CI_JOB_TOKEN")
+}
+
+func (r *Resolver) fromLogin(host, _ string) string {
+	if r.store == nil {
+		return ""
+	}
+
+	token, _, err := r.store.Token(context.Background(), host)
+	if err != nil {
+		slog.Warn("failed to load stored credentials", slog.String("host", host), slog.Any("error", err))
+
+		return ""
+	}
+
+	return token
+}
+
+func (r *Resolver) fromGitCredential(host, _ string) string {
+	if r.gitCredential == nil {
+		return ""
+	}
+
+	token, err := r.gitCredential(host)
+	if err != nil {
+		slog.Debug("git credential fill failed", slog.String("host", host), slog.Any("error", err))
+
+		return ""
+	}
+
+	return token
+}
+
+// configDir resolves a tool's config directory, honouring its override variable and XDG_CONFIG_HOME.
+func (r *Resolver) configDir(overrideEnv, name string) string {
+	if dir := r.getenv(overrideEnv); dir != "" {
+		return dir
+	}
+
+	if dir := r.getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+
+	return filepath.Join(r.homeDir, ".config", name)
+}
+
+func inferProvider(host string) string {
+	switch {
+	case strings.Contains(host, "github"):
+		return providerGitHub
+	case strings.Contains(host, "gitlab"):
+		return providerGitLab
+	default:
+		return ""
+	}
+}
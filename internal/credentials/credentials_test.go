@@ -0,0 +1,144 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/auth"
+)
+
+func newTestResolver(t *testing.T, env map[string]string, gitPassword string) *Resolver {
+	t.Helper()
+
+	return &Resolver{
+		getenv:  func(name string) string { return env[name] },
+		homeDir: t.TempDir(),
+		gitCredential: func(host string) (string, error) {
+			if gitPassword == "" {
+				return "", errors.New("no credential")
+			}
+			return gitPassword, nil
+		},
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatalf("failed to create %s: %s", filepath.Dir(path), err)
+	}
+
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %s", path, err)
+	}
+}
+
+func Test_Resolver_Lookup(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		host     string
+		provider string
+		env      map[string]string
+		files    map[string]string
+		git      string
+		stored   map[string]auth.Credential
+
+		want   Credential
+		wantOk bool
+	}{
+		{
+			name: "GitHub token from environment",
+			host: "github.com",
+			env:  map[string]string{"GITHUB_TOKEN": "ghp_env", "GITLAB_TOKEN": "glpat-env"},
+			want: Credential{Token: "ghp_env", Source: SourceEnv}, wantOk: true,
+		},
+		{
+			name:     "GitHub Enterprise token from environment",
+			host:     "github.example.com",
+			provider: "github",
+			env:      map[string]string{"GITHUB_TOKEN": "ghp_env", "GH_ENTERPRISE_TOKEN": "ghp_enterprise"},
+			want:     Credential{Token: "ghp_enterprise", Source: SourceEnv}, wantOk: true,
+		},
+		{
+			name: "CI job token only for the job's instance",
+			host: "gitlab.example.com",
+			env:  map[string]string{"CI_JOB_TOKEN": "job-token", "CI_SERVER_HOST": "gitlab.example.com"},
+			want: Credential{Token: "job-token", Source: SourceCIJobToken}, wantOk: true,
+		},
+		{
+			name: "CI job token ignored for other instances",
+			host: "gitlab.com",
+			env:  map[string]string{"CI_JOB_TOKEN": "job-token", "CI_SERVER_HOST": "gitlab.example.com"},
+		},
+		{
+			name:   "Token stored by auth login",
+			host:   "github.com",
+			stored: map[string]auth.Credential{"github.com": {Provider: "github", Token: "gho_login"}},
+			files:  map[string]string{".config/gh/hosts.yml": "github.com:\n  oauth_token: gho_cli\n"},
+			want:   Credential{Token: "gho_login", Source: SourceLogin}, wantOk: true,
+		},
+		{
+			name:   "Expired stored token is skipped",
+			host:   "gitlab.com",
+			stored: map[string]auth.Credential{"gitlab.com": {Provider: "gitlab", Token: "expired", ExpiresAt: time.Now().Add(-time.Hour)}},
+			git:    "password-from-helper",
+			want:   Credential{Token: "password-from-helper", Source: SourceGitCredential}, wantOk: true,
+		},
+		{
+			name:  "gh hosts.yml",
+			host:  "github.com",
+			files: map[string]string{".config/gh/hosts.yml": "github.com:\n  user: octocat\n  oauth_token: gho_cli\n  git_protocol: ssh\n"},
+			want:  Credential{Token: "gho_cli", Source: SourceGitHubCLI}, wantOk: true,
+		},
+		{
+			name:  "glab config.yml",
+			host:  "gitlab.com",
+			files: map[string]string{".config/glab-cli/config.yml": "git_protocol: ssh\nhosts:\n  gitlab.com:\n    token: glpat-cli\n    api_protocol: https\n"},
+			want:  Credential{Token: "glpat-cli", Source: SourceGitLabCLI}, wantOk: true,
+		},
+		{
+			name:  "netrc",
+			host:  "gitlab.com",
+			files: map[string]string{".netrc": "machine github.com login octocat password ghp_netrc\nmachine gitlab.com\n  login oauth2\n  password glpat-netrc\n"},
+			want:  Credential{Token: "glpat-netrc", Source: SourceNetrc}, wantOk: true,
+		},
+		{
+			name: "git credential fill",
+			host: "git.example.com",
+			git:  "password-from-helper",
+			want: Credential{Token: "password-from-helper", Source: SourceGitCredential}, wantOk: true,
+		},
+		{
+			name: "Nothing found",
+			host: "git.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			r := newTestResolver(ts, tt.env, tt.git)
+
+			r.store = auth.NewStore(filepath.Join(r.homeDir, "credentials.yaml"))
+			for host, cred := range tt.stored {
+				if err := r.store.Save(host, cred); err != nil {
+					ts.Fatalf("failed to store credential: %s", err)
+				}
+			}
+
+			for name, contents := range tt.files {
+				writeFile(ts, filepath.Join(r.homeDir, name), contents)
+			}
+
+			got, ok := r.Lookup(tt.host, tt.provider)
+			if ok != tt.wantOk || got != tt.want {
+				ts.Errorf("expected (%v, %v), got (%v, %v)", tt.want, tt.wantOk, got, ok)
+			}
+		})
+	}
+
+}
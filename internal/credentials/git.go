@@ -0,0 +1,39 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const gitCredentialTimeout = 5 * time.Second
+
+// gitCredentialFill asks git's configured credential helpers for the host's HTTPS password.
+// Interactive prompts are disabled so that a missing credential fails instead of blocking.
+func gitCredentialFill(host string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCredentialTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GIT_ASKPASS=")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git credential fill: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return password, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,72 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func (r *Resolver) fromNetrc(host, _ string) string {
+	path := r.getenv("NETRC")
+	if path == "" {
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+
+		path = filepath.Join(r.homeDir, name)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return parseNetrc(string(b))[host]
+}
+
+// parseNetrc returns the password of each machine in a .netrc file. Macros are skipped and
+// the default entry is ignored, since it would otherwise leak credentials to any host.
+func parseNetrc(contents string) map[string]string {
+	passwords := make(map[string]string)
+
+	var machine string
+
+	lines := strings.Split(contents, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+
+		// A macdef's body runs until the next blank line
+		if len(fields) > 0 && fields[0] == "macdef" {
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			}
+
+			continue
+		}
+
+		for j := 0; j < len(fields); j++ {
+			switch fields[j] {
+			case "machine":
+				machine = ""
+				if j+1 < len(fields) {
+					j++
+					machine = fields[j]
+				}
+			case "default":
+				machine = ""
+			case "login", "account":
+				j++
+			case "password":
+				if j+1 < len(fields) {
+					j++
+					if machine != "" {
+						passwords[machine] = fields[j]
+					}
+				}
+			}
+		}
+	}
+
+	return passwords
+}
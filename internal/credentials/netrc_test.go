@@ -0,0 +1,29 @@
+package credentials
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseNetrc(t *testing.T) {
+	contents := `
+machine github.com login octocat password ghp_netrc
+default login anonymous password secret
+
+macdef init
+machine evil.example.com password leaked
+
+machine gitlab.com
+  login oauth2
+  password glpat-netrc
+`
+
+	want := map[string]string{
+		"github.com": "ghp_netrc",
+		"gitlab.com": "glpat-netrc",
+	}
+
+	if got := parseNetrc(contents); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,240 @@
+// Package daemon watches the latest pipelines of many repositories, keeping their state in memory
+// and serving it over HTTP so that other tools needn't poll the providers themselves.
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+// subscriberBuffer is the number of transitions buffered for each subscriber before they are dropped.
+const subscriberBuffer = 16
+
+// Target is a watched repository and ref along with the service used to poll its pipelines.
+type Target struct {
+	config.Watch
+	Service  *checker.Service
+	Schedule func(*gateway.Pipeline) checker.Scheduler
+}
+
+// State is the latest known pipeline of a watched repository and ref.
+type State struct {
+	Repo            string    `json:"repo"`
+	Ref             string    `json:"ref"`
+	PipelineID      int       `json:"pipeline_id,omitempty"`
+	SHA             string    `json:"sha,omitempty"`
+	Status          string    `json:"status,omitempty"`
+	Failed          bool      `json:"failed"`
+	FailedJobs      []string  `json:"failed_jobs"`
+	DurationSeconds int       `json:"duration_seconds"`
+	URL             string    `json:"url,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Transition is a change in a watched pipeline's state, as sent to subscribers.
+type Transition struct {
+	Event          checker.EventType `json:"event"`
+	PreviousStatus string            `json:"previous_status,omitempty"`
+	State
+}
+
+// Daemon holds the state of every watched pipeline and fans out their transitions.
+type Daemon struct {
+	// OnEvent, if set, is called with every pipeline event observed while running.
+	OnEvent func(checker.Event)
+
+	interval time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	states      map[config.Watch]State
+	subscribers map[chan Transition]struct{}
+}
+
+// New creates a daemon checking watched refs for new pipelines at the given interval.
+func New(interval time.Duration) *Daemon {
+	return &Daemon{
+		interval:    interval,
+		now:         time.Now,
+		states:      make(map[config.Watch]State),
+		subscribers: make(map[chan Transition]struct{}),
+	}
+}
+
+// Run watches every target until the context is cancelled.
+func (d *Daemon) Run(ctx context.Context, targets []Target) {
+	var wg sync.WaitGroup
+
+	for _, target := range targets {
+		d.set(target.Watch, State{Repo: target.Repo, Ref: target.Ref, FailedJobs: []string{}, UpdatedAt: d.now()})
+
+		wg.Add(1)
+
+		go func(t Target) {
+			defer wg.Done()
+			d.watch(ctx, t)
+		}(target)
+	}
+
+	wg.Wait()
+}
+
+// watch follows a target's latest pipeline, watching each new or pending pipeline to completion.
+// Pipelines that had already completed when the daemon started are recorded without being reported.
+func (d *Daemon) watch(ctx context.Context, t Target) {
+	var lastID int
+
+	for first := true; ; first = false {
+		pipeline, err := t.Service.LatestPipeline(t.Repo, t.Ref)
+
+		switch {
+		case err != nil:
+			slog.Error("failed to find latest pipeline", slog.String("repo", t.Repo), slog.String("ref", t.Ref), slog.Any("error", err))
+			d.setError(t.Watch, err)
+		case pipeline == nil:
+		case first && !t.Service.IsPending(pipeline):
+			d.record(t.Watch, checker.Event{Type: checker.EventCompleted, Pipeline: pipeline}, false)
+			lastID = pipeline.ID
+		case pipeline.ID != lastID || t.Service.IsPending(pipeline):
+			for event := range t.Service.WatchPipeline(pipeline, t.Schedule(pipeline)) {
+				d.record(t.Watch, event, true)
+			}
+
+			lastID = pipeline.ID
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.interval):
+		}
+	}
+}
+
+// States returns the state of every watched pipeline, ordered by repository and ref.
+func (d *Daemon) States() []State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	states := make([]State, 0, len(d.states))
+	for _, s := range d.states {
+		states = append(states, s)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Repo != states[j].Repo {
+			return states[i].Repo < states[j].Repo
+		}
+
+		return states[i].Ref < states[j].Ref
+	})
+
+	return states
+}
+
+// Subscribe returns a channel receiving every transition until the returned function is called.
+// Transitions are dropped for subscribers that fall behind.
+func (d *Daemon) Subscribe() (<-chan Transition, func()) {
+	ch := make(chan Transition, subscriberBuffer)
+
+	d.mu.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.mu.Unlock()
+
+	return ch, func() {
+		d.mu.Lock()
+		delete(d.subscribers, ch)
+		d.mu.Unlock()
+	}
+}
+
+// record updates a watch's state from a pipeline event, publishing and reporting it if requested.
+func (d *Daemon) record(w config.Watch, event checker.Event, publish bool) {
+	if event.Type == checker.EventError {
+		d.setError(w, event.Err)
+	}
+
+	// Downstream pipelines are reported, but the watch's state follows the watched pipeline
+	if event.Type == checker.EventError || event.Type == checker.EventDownstreamChanged {
+		if publish && d.OnEvent != nil {
+			d.OnEvent(event)
+		}
+
+		return
+	}
+
+	p := event.Pipeline
+	state := State{
+		Repo:            w.Repo,
+		Ref:             w.Ref,
+		PipelineID:      p.ID,
+		SHA:             p.CommitSha,
+		Status:          p.Status,
+		Failed:          event.Failed,
+		FailedJobs:      []string{},
+		DurationSeconds: int(p.Duration().Seconds()),
+		URL:             p.URL,
+		UpdatedAt:       d.now(),
+	}
+
+	for _, job := range event.FailedJobs {
+		state.FailedJobs = append(state.FailedJobs, job.Name)
+	}
+
+	d.mu.Lock()
+	// Failed jobs are only known once a pipeline completes, so keep them until the next one starts
+	if previous := d.states[w]; previous.PipelineID == p.ID && len(event.FailedJobs) == 0 {
+		state.FailedJobs = previous.FailedJobs
+	}
+
+	d.states[w] = state
+	d.mu.Unlock()
+
+	if !publish {
+		return
+	}
+
+	if d.OnEvent != nil {
+		d.OnEvent(event)
+	}
+
+	d.publish(Transition{Event: event.Type, PreviousStatus: event.PreviousStatus, State: state})
+}
+
+func (d *Daemon) setError(w config.Watch, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.states[w]
+	state.Error = err.Error()
+	state.UpdatedAt = d.now()
+	d.states[w] = state
+}
+
+func (d *Daemon) set(w config.Watch, state State) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.states[w] = state
+}
+
+// publish sends a transition to every subscriber.
+func (d *Daemon) publish(t Transition) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for ch := range d.subscribers {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+}
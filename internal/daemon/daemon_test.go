@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+// fakeClient serves a single pipeline whose status can be changed by the test.
+type fakeClient struct {
+	mu       sync.Mutex
+	pipeline gateway.Pipeline
+}
+
+func (c *fakeClient) setStatus(status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pipeline.Status = status
+}
+
+func (c *fakeClient) get() *gateway.Pipeline {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.pipeline
+
+	return &p
+}
+
+func (c *fakeClient) GetPipelineBySha(string, string) (*gateway.Pipeline, error) { return c.get(), nil }
+func (c *fakeClient) GetPipeline(string, int) (*gateway.Pipeline, error)         { return c.get(), nil }
+func (c *fakeClient) GetPipelineJobs(string, int) ([]gateway.Job, error) {
+	return []gateway.Job{{Name: "test", Status: "failed"}}, nil
+}
+func (c *fakeClient) ListPipelines(string, gateway.ListOptions) ([]gateway.Pipeline, error) {
+	return []gateway.Pipeline{*c.get()}, nil
+}
+func (c *fakeClient) IsStatusPending(status string) bool { return status == "running" }
+func (c *fakeClient) IsStatusFailed(status string) bool  { return status == "failed" }
+
+func Test_Daemon(t *testing.T) {
+	client := &fakeClient{pipeline: gateway.Pipeline{ID: 7, ProjectID: "1", Ref: "main", Status: "running"}}
+	svc := checker.New(client, nil)
+
+	d := New(10 * time.Millisecond)
+
+	var (
+		mu     sync.Mutex
+		events []checker.Event
+	)
+	d.OnEvent = func(e checker.Event) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/events?ref=main")
+	if err != nil {
+		t.Fatalf("failed to subscribe to events: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected event stream, got %s", ct)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go d.Run(ctx, []Target{{
+		Watch:    config.Watch{Repo: "https://gitlab.com/group/project", Ref: "main"},
+		Service:  svc,
+		Schedule: func(*gateway.Pipeline) checker.Scheduler { return checker.FixedScheduler(10 * time.Millisecond) },
+	}})
+
+	time.AfterFunc(50*time.Millisecond, func() { client.setStatus("failed") })
+
+	var transitions []Transition
+
+	scanner := bufio.NewScanner(resp.Body)
+	for len(transitions) < 3 && scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var tr Transition
+			if err := json.Unmarshal([]byte(data), &tr); err != nil {
+				t.Fatalf("failed to decode transition %q: %s", data, err)
+			}
+
+			transitions = append(transitions, tr)
+		}
+	}
+
+	if len(transitions) != 3 {
+		t.Fatalf("expected 3 transitions, got %v", transitions)
+	}
+
+	if transitions[1].PreviousStatus != "running" || transitions[1].Status != "failed" {
+		t.Errorf("expected running to failed transition, got %v", transitions[1])
+	}
+
+	if transitions[2].Event != checker.EventCompleted || len(transitions[2].FailedJobs) != 1 {
+		t.Errorf("expected completion with failed job, got %v", transitions[2])
+	}
+
+	mu.Lock()
+	if len(events) != 3 {
+		t.Errorf("expected 3 reported events, got %d", len(events))
+	}
+	mu.Unlock()
+
+	var states []State
+
+	stateResp, err := http.Get(server.URL + "/api/v1/pipelines?repo=https://gitlab.com/group/project")
+	if err != nil {
+		t.Fatalf("failed to get pipelines: %s", err)
+	}
+	defer stateResp.Body.Close()
+
+	if err := json.NewDecoder(stateResp.Body).Decode(&states); err != nil {
+		t.Fatalf("failed to decode pipelines: %s", err)
+	}
+
+	if len(states) != 1 || states[0].PipelineID != 7 || states[0].Status != "failed" || states[0].FailedJobs[0] != "test" {
+		t.Errorf("expected failed pipeline state, got %v", states)
+	}
+}
+
+func Test_Daemon_IgnoresCompletedPipelinesOnStart(t *testing.T) {
+	client := &fakeClient{pipeline: gateway.Pipeline{ID: 7, ProjectID: "1", Ref: "main", Status: "success"}}
+
+	d := New(time.Hour)
+	d.OnEvent = func(e checker.Event) {
+		t.Errorf("expected no events to be reported, got %v", e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	d.Run(ctx, []Target{{
+		Watch:   config.Watch{Repo: "https://gitlab.com/group/project"},
+		Service: checker.New(client, nil),
+	}})
+
+	states := d.States()
+	if len(states) != 1 || states[0].Status != "success" {
+		t.Errorf("expected completed pipeline to be recorded, got %v", states)
+	}
+}
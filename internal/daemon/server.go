@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// keepAliveInterval is how often idle event streams are sent a comment to keep them open.
+const keepAliveInterval = 30 * time.Second
+
+// Handler serves the daemon's HTTP API:
+//
+//	GET /api/v1/pipelines   the state of every watched pipeline, filtered by the repo and ref
+//	                        query parameters if given
+//	GET /api/v1/events      a Server-Sent Events stream of pipeline transitions, filtered in the
+//	                        same way
+//	GET /healthz            responds with 200 OK while the daemon is running
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/pipelines", getOnly(d.handlePipelines))
+	mux.HandleFunc("/api/v1/events", getOnly(d.handleEvents))
+	mux.HandleFunc("/healthz", getOnly(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+
+	return mux
+}
+
+func getOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func (d *Daemon) handlePipelines(w http.ResponseWriter, r *http.Request) {
+	states := []State{}
+
+	for _, s := range d.States() {
+		if matches(r, s) {
+			states = append(states, s)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(states) //nolint:errcheck
+}
+
+func (d *Daemon) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	transitions, unsubscribe := d.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case t := <-transitions:
+			if !matches(r, t.State) {
+				continue
+			}
+
+			data, err := json.Marshal(t)
+			if err != nil {
+				continue
+			}
+
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", t.Event, data)
+		}
+
+		flusher.Flush()
+	}
+}
+
+// matches reports whether a state matches the request's repo and ref query parameters.
+func matches(r *http.Request, s State) bool {
+	query := r.URL.Query()
+
+	if repo := query.Get("repo"); repo != "" && repo != s.Repo {
+		return false
+	}
+
+	if ref := query.Get("ref"); ref != "" && ref != s.Ref {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,85 @@
+// Package excerpt extracts the lines of a failed job's log that explain its failure.
+package excerpt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Extractor returns the lines of a normalised log relevant to a job's failure, or nil if it
+// recognises none.
+type Extractor func(lines []string) []string
+
+// Excerpt is the part of a job's log that explains its failure.
+type Excerpt struct {
+	// Extractor is the name of the extractor that recognised the log.
+	Extractor string
+	Lines     []string
+	// Omitted is the number of relevant lines left out to keep the excerpt concise.
+	Omitted int
+}
+
+func (e Excerpt) String() string {
+	s := strings.Join(e.Lines, "\n")
+	if e.Omitted > 0 {
+		s += fmt.Sprintf("\n... %d more lines", e.Omitted)
+	}
+
+	return s
+}
+
+type namedExtractor struct {
+	name    string
+	extract Extractor
+}
+
+// Registry tries extractors in the order they were registered until one recognises a log.
+type Registry struct {
+	extractors []namedExtractor
+	maxLines   int
+}
+
+// NewRegistry creates an empty registry whose excerpts are limited to maxLines lines, or
+// unlimited if it is zero.
+func NewRegistry(maxLines int) *Registry {
+	return &Registry{maxLines: maxLines}
+}
+
+// Default returns a registry of the built-in extractors, most specific first, falling back to the
+// log's last lines.
+func Default(maxLines int) *Registry {
+	return NewRegistry(maxLines).
+		Register("go test", GoTest).
+		Register("compiler", CompilerErrors).
+		Register("github", GitHubErrors).
+		Register("tail", Tail(maxLines))
+}
+
+// Register adds an extractor, to be tried after those already registered.
+func (r *Registry) Register(name string, extract Extractor) *Registry {
+	r.extractors = append(r.extractors, namedExtractor{name: name, extract: extract})
+
+	return r
+}
+
+// Extract returns the excerpt of a raw job log found by the first extractor to recognise it, or an
+// empty excerpt if none does.
+func (r *Registry) Extract(log string) Excerpt {
+	lines := Normalise(log)
+
+	for _, e := range r.extractors {
+		found := e.extract(lines)
+		if len(found) == 0 {
+			continue
+		}
+
+		excerpt := Excerpt{Extractor: e.name, Lines: found}
+		if r.maxLines > 0 && len(found) > r.maxLines {
+			excerpt.Lines, excerpt.Omitted = found[:r.maxLines], len(found)-r.maxLines
+		}
+
+		return excerpt
+	}
+
+	return Excerpt{}
+}
@@ -0,0 +1,229 @@
+package excerpt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const gitlabGoTestLog = "\x1b[0KRunning with gitlab-runner 16.11.0 (91a27b2a)\x1b[0;m\n" +
+	"section_start:1714564800:prepare_executor\r\x1b[0K\x1b[0K\x1b[36;1mPreparing the \"docker\" executor\x1b[0;m\x1b[0;m\n" +
+	"\x1b[0KUsing docker image golang:1.21\x1b[0;m\n" +
+	"section_end:1714564801:prepare_executor\r\x1b[0K\n" +
+	"section_start:1714564801:step_script\r\x1b[0K\x1b[0K\x1b[36;1mExecuting \"step_script\" stage of the job script\x1b[0;m\x1b[0;m\n" +
+	"\x1b[32;1m$ go test ./...\x1b[0;m\n" +
+	"ok  \tgithub.com/acme/app/internal/config\t0.012s\n" +
+	"--- FAIL: TestParse (0.00s)\n" +
+	"    --- PASS: TestParse/empty (0.00s)\n" +
+	"    --- FAIL: TestParse/nested (0.00s)\n" +
+	"        parse_test.go:42: expected 2 keys, got 1\n" +
+	"FAIL\n" +
+	"FAIL\tgithub.com/acme/app/internal/parse\t0.015s\n" +
+	"FAIL\n" +
+	"section_end:1714564830:step_script\r\x1b[0K\n" +
+	"section_start:1714564830:cleanup_file_variables\r\x1b[0K\x1b[0K\x1b[36;1mCleaning up project directory and file based variables\x1b[0;m\x1b[0;m\n" +
+	"section_end:1714564831:cleanup_file_variables\r\x1b[0K\n" +
+	"\x1b[31;1mERROR: Job failed: exit code 1\n" +
+	"\x1b[0;m\n"
+
+const githubVerboseGoTestLog = "2024-05-01T12:00:00.0000000Z ##[group]Run go test -v ./...\n" +
+	"2024-05-01T12:00:00.0000000Z ##[endgroup]\n" +
+	"2024-05-01T12:00:01.0000000Z === RUN   TestParse\n" +
+	"2024-05-01T12:00:01.0000000Z === RUN   TestParse/empty\n" +
+	"2024-05-01T12:00:01.0000000Z === RUN   TestParse/nested\n" +
+	"2024-05-01T12:00:01.0000000Z     parse_test.go:42: expected 2 keys, got 1\n" +
+	"2024-05-01T12:00:01.0000000Z     parse_test.go:43: expected key \"b\"\n" +
+	"2024-05-01T12:00:01.0000000Z --- FAIL: TestParse (0.00s)\n" +
+	"2024-05-01T12:00:01.0000000Z     --- PASS: TestParse/empty (0.00s)\n" +
+	"2024-05-01T12:00:01.0000000Z     --- FAIL: TestParse/nested (0.00s)\n" +
+	"2024-05-01T12:00:01.0000000Z === RUN   TestLoad\n" +
+	"2024-05-01T12:00:01.0000000Z     load_test.go:17: unexpected error occurred: open config.yaml: no such file or directory\n" +
+	"2024-05-01T12:00:01.0000000Z --- FAIL: TestLoad (0.00s)\n" +
+	"2024-05-01T12:00:01.0000000Z === RUN   TestSave\n" +
+	"2024-05-01T12:00:01.0000000Z --- PASS: TestSave (0.00s)\n" +
+	"2024-05-01T12:00:01.0000000Z FAIL\n" +
+	"2024-05-01T12:00:01.0000000Z FAIL\tgithub.com/acme/app/internal/parse\t0.015s\n" +
+	"2024-05-01T12:00:01.1000000Z ##[error]Process completed with exit code 1.\n"
+
+const githubCompilerLog = "2024-05-01T12:00:00.0000000Z ##[group]Run go build ./...\n" +
+	"2024-05-01T12:00:00.0000000Z \x1b[36;1mgo build ./...\x1b[0m\n" +
+	"2024-05-01T12:00:00.0000000Z ##[endgroup]\n" +
+	"2024-05-01T12:00:01.0000000Z go: downloading github.com/acme/lib v1.2.0\n" +
+	"2024-05-01T12:00:05.0000000Z # github.com/acme/app/internal/server\n" +
+	"2024-05-01T12:00:05.0000000Z internal/server/server.go:18:2: undefined: handler\n" +
+	"2024-05-01T12:00:05.0000000Z internal/server/routes.go:7:10: warning: unused variable\n" +
+	"2024-05-01T12:00:05.0000000Z internal/server/routes.go:9:5: cannot use x (variable of type int) as string value\n" +
+	"2024-05-01T12:00:05.0000000Z FAIL\tgithub.com/acme/app/internal/server [build failed]\n" +
+	"2024-05-01T12:00:05.1000000Z ##[error]Process completed with exit code 1.\n"
+
+const githubErrorLog = "2024-05-01T12:00:00.0000000Z ##[group]Run ./scripts/check-licenses.sh\n" +
+	"2024-05-01T12:00:00.0000000Z ##[endgroup]\n" +
+	"2024-05-01T12:00:01.0000000Z Checking 142 dependencies\n" +
+	"2024-05-01T12:00:02.0000000Z ##[error]github.com/acme/gpl-lib uses a disallowed license: GPL-3.0\n" +
+	"2024-05-01T12:00:02.1000000Z ##[error]Process completed with exit code 1.\n"
+
+const scriptTailLog = "$ npm ci\n" +
+	"added 812 packages in 14s\r\n" +
+	"$ npm run deploy\n" +
+	"Uploading [=====>      ] 45%\rUploading [==========>  ] 90%\rUploading failed\n" +
+	"\n" +
+	"Error: connect ECONNREFUSED 10.0.0.12:443\n" +
+	"    at TCPConnectWrap.afterConnect [as oncomplete] (node:net:1555:16)\n" +
+	"ERROR: Job failed: exit code 1\n"
+
+func Test_Registry_Extract(ts *testing.T) {
+	tests := []struct {
+		name      string
+		log       string
+		maxLines  int
+		extractor string
+		lines     []string
+		omitted   int
+	}{
+		{
+			name:      "GitLab Go test failure",
+			log:       gitlabGoTestLog,
+			maxLines:  20,
+			extractor: "go test",
+			lines: []string{
+				"--- FAIL: TestParse (0.00s)",
+				"    --- FAIL: TestParse/nested (0.00s)",
+				"        parse_test.go:42: expected 2 keys, got 1",
+				"FAIL\tgithub.com/acme/app/internal/parse\t0.015s",
+			},
+		},
+		{
+			name:      "GitHub verbose Go test failure",
+			log:       githubVerboseGoTestLog,
+			maxLines:  20,
+			extractor: "go test",
+			lines: []string{
+				"--- FAIL: TestParse (0.00s)",
+				"    --- FAIL: TestParse/nested (0.00s)",
+				"    parse_test.go:42: expected 2 keys, got 1",
+				"    parse_test.go:43: expected key \"b\"",
+				"--- FAIL: TestLoad (0.00s)",
+				"    load_test.go:17: unexpected error occurred: open config.yaml: no such file or directory",
+				"FAIL\tgithub.com/acme/app/internal/parse\t0.015s",
+			},
+		},
+		{
+			name:      "GitHub Go build failure",
+			log:       githubCompilerLog,
+			maxLines:  20,
+			extractor: "compiler",
+			lines: []string{
+				"internal/server/server.go:18:2: undefined: handler",
+				"internal/server/routes.go:9:5: cannot use x (variable of type int) as string value",
+			},
+		},
+		{
+			name: "rustc and tsc errors",
+			log: "   Compiling app v0.1.0 (/builds/acme/app)\n" +
+				"error[E0425]: cannot find value `handler` in this scope\n" +
+				"  --> src/main.rs:18:5\n" +
+				"warning: unused import: `std::fmt`\n" +
+				"src/index.ts(7,10): error TS2322: Type 'number' is not assignable to type 'string'.\n",
+			maxLines:  20,
+			extractor: "compiler",
+			lines: []string{
+				"error[E0425]: cannot find value `handler` in this scope",
+				"  --> src/main.rs:18:5",
+				"src/index.ts(7,10): error TS2322: Type 'number' is not assignable to type 'string'.",
+			},
+		},
+		{
+			name:      "GitHub error annotation",
+			log:       githubErrorLog,
+			maxLines:  20,
+			extractor: "github",
+			lines:     []string{"github.com/acme/gpl-lib uses a disallowed license: GPL-3.0"},
+		},
+		{
+			name:      "Last lines before exit code",
+			log:       scriptTailLog,
+			maxLines:  3,
+			extractor: "tail",
+			lines: []string{
+				"Uploading failed",
+				"Error: connect ECONNREFUSED 10.0.0.12:443",
+				"    at TCPConnectWrap.afterConnect [as oncomplete] (node:net:1555:16)",
+			},
+		},
+		{
+			name:      "Excerpts are limited",
+			log:       githubCompilerLog,
+			maxLines:  1,
+			extractor: "compiler",
+			lines:     []string{"internal/server/server.go:18:2: undefined: handler"},
+			omitted:   1,
+		},
+		{
+			name: "Empty log",
+			log:  "",
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			got := Default(test.maxLines).Extract(test.log)
+
+			if got.Extractor != test.extractor {
+				t.Errorf("expected extractor %q, got %q", test.extractor, got.Extractor)
+			}
+
+			if !reflect.DeepEqual(test.lines, got.Lines) {
+				t.Errorf("expected lines:\n%s\ngot:\n%s", strings.Join(test.lines, "\n"), strings.Join(got.Lines, "\n"))
+			}
+
+			if got.Omitted != test.omitted {
+				t.Errorf("expected %d omitted lines, got %d", test.omitted, got.Omitted)
+			}
+		})
+	}
+}
+
+func Test_Registry_Register(t *testing.T) {
+	registry := NewRegistry(0).
+		Register("never", func([]string) []string { return nil }).
+		Register("rspec", func(lines []string) []string {
+			var found []string
+			for _, line := range lines {
+				if strings.HasPrefix(line, "rspec ./spec/") {
+					found = append(found, line)
+				}
+			}
+
+			return found
+		}).
+		Register("tail", Tail(1))
+
+	got := registry.Extract("Failed examples:\n\nrspec ./spec/user_spec.rb:12 # User is valid\n")
+
+	want := Excerpt{Extractor: "rspec", Lines: []string{"rspec ./spec/user_spec.rb:12 # User is valid"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_Normalise(t *testing.T) {
+	got := Normalise(gitlabGoTestLog)
+
+	// Only the script section is kept, without escape sequences or section markers
+	want := []string{
+		"Executing \"step_script\" stage of the job script",
+		"$ go test ./...",
+		"ok  \tgithub.com/acme/app/internal/config\t0.012s",
+		"--- FAIL: TestParse (0.00s)",
+		"    --- PASS: TestParse/empty (0.00s)",
+		"    --- FAIL: TestParse/nested (0.00s)",
+		"        parse_test.go:42: expected 2 keys, got 1",
+		"FAIL",
+		"FAIL\tgithub.com/acme/app/internal/parse\t0.015s",
+		"FAIL",
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected lines:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
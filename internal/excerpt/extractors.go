@@ -0,0 +1,185 @@
+package excerpt
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxPanicLines bounds how much of a panic's stack trace is kept.
+const maxPanicLines = 15
+
+var (
+	// compilerPatterns match the diagnostics of common compilers and linters.
+	compilerPatterns = []*regexp.Regexp{
+		// file:line[:column]: message, as printed by Go, GCC, Clang and most linters
+		regexp.MustCompile(`^\S+\.\w+:\d+(:\d+)?: `),
+		// file(line,column): error, as printed by TypeScript and MSBuild
+		regexp.MustCompile(`^\S+\.\w+\(\d+,\d+\): error`),
+		// error[code]: message, followed by its location, as printed by Rust
+		regexp.MustCompile(`^error(\[\w+\])?: `),
+		regexp.MustCompile(`^\s+--> \S+:\d+:\d+`),
+	}
+
+	warningPattern = regexp.MustCompile(`(?i)\bwarning\b`)
+
+	// exitPattern matches the lines with which GitLab and GitHub report a job's exit code.
+	exitPattern = regexp.MustCompile(`ERROR: Job failed|Process completed with exit code \d+`)
+)
+
+// GoTest extracts failed Go tests along with their output, panics, and the packages that failed.
+// With -v, a test's output is printed between its === RUN and --- FAIL lines rather than beneath
+// the latter, so it is collected first and placed beneath the test's --- FAIL line.
+func GoTest(lines []string) []string {
+	output := verboseTestOutput(lines)
+
+	var found []string
+
+	// failed adds a --- FAIL line along with the test's output when run with -v
+	failed := func(line string) {
+		found = append(found, line)
+		found = append(found, output[failedTestName(line)]...)
+	}
+
+	recognised := false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " \t")
+
+		switch {
+		case strings.HasPrefix(trimmed, "--- FAIL"):
+			recognised = true
+			indent := len(line) - len(trimmed)
+
+			failed(line)
+
+			// The test's output and subtests are indented beneath it
+			for i+1 < len(lines) && indentation(lines[i+1]) > indent {
+				i++
+
+				switch subtest := strings.TrimSpace(lines[i]); {
+				case strings.HasPrefix(subtest, "--- FAIL"):
+					failed(lines[i])
+				case !strings.HasPrefix(subtest, "--- PASS"):
+					found = append(found, lines[i])
+				}
+			}
+		case strings.HasPrefix(line, "panic: "):
+			recognised = true
+			end := min(i+maxPanicLines, len(lines))
+
+			for ; i < end && strings.TrimSpace(lines[i]) != ""; i++ {
+				found = append(found, lines[i])
+			}
+		case strings.HasPrefix(line, "FAIL\t"):
+			found = append(found, line)
+		}
+	}
+
+	// Packages failing alone are build failures, better explained by the compiler's errors
+	if !recognised {
+		return nil
+	}
+
+	return found
+}
+
+// verboseTestOutput returns the output of each test run with -v, which follows the === RUN line
+// of the test, or the === CONT or === NAME line of a test resumed after others printed output.
+func verboseTestOutput(lines []string) map[string][]string {
+	output := make(map[string][]string)
+
+	var name string
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) == 3 && fields[0] == "===" && slices.Contains([]string{"RUN", "CONT", "NAME"}, fields[1]):
+			name = fields[2]
+		case name != "" && indentation(line) > 0 && !strings.HasPrefix(strings.TrimSpace(line), "--- "):
+			output[name] = append(output[name], line)
+		default:
+			name = ""
+		}
+	}
+
+	return output
+}
+
+// failedTestName returns the name of the test of a --- FAIL line.
+func failedTestName(line string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "--- FAIL: "), " ")
+
+	return name
+}
+
+// CompilerErrors extracts compiler and linter diagnostics, leaving out warnings.
+func CompilerErrors(lines []string) []string {
+	var found []string
+
+	for _, line := range lines {
+		if warningPattern.MatchString(line) {
+			continue
+		}
+
+		if slices.ContainsFunc(compilerPatterns, func(p *regexp.Regexp) bool { return p.MatchString(line) }) {
+			found = append(found, line)
+		}
+	}
+
+	return found
+}
+
+// GitHubErrors extracts the errors GitHub Actions logs with ##[error], such as those reported
+// through the ::error:: workflow command, other than the exit code of the failed step.
+func GitHubErrors(lines []string) []string {
+	var found []string
+
+	for _, line := range lines {
+		_, message, ok := strings.Cut(line, "##[error]")
+		if ok && !exitPattern.MatchString(message) {
+			found = append(found, message)
+		}
+	}
+
+	return found
+}
+
+// Tail returns an extractor of the last n non-empty lines before the job's exit code is reported,
+// which for most failures include the failing command's output.
+func Tail(n int) Extractor {
+	return func(lines []string) []string {
+		end := len(lines)
+		for i := len(lines) - 1; i >= 0; i-- {
+			if exitPattern.MatchString(lines[i]) {
+				end = i
+
+				break
+			}
+		}
+
+		var found []string
+
+		for i := end - 1; i >= 0 && (n == 0 || len(found) < n); i-- {
+			if strings.TrimSpace(lines[i]) != "" {
+				found = append(found, lines[i])
+			}
+		}
+
+		slices.Reverse(found)
+
+		return found
+	}
+}
+
+// indentation returns the number of leading spaces and tabs of a line, treating blank lines as unindented.
+func indentation(line string) int {
+	trimmed := strings.TrimLeft(line, " \t")
+	if trimmed == "" {
+		return 0
+	}
+
+	return len(line) - len(trimmed)
+}
@@ -0,0 +1,68 @@
+package excerpt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// scriptSection is the GitLab log section holding the output of a job's script.
+const scriptSection = "step_script"
+
+var (
+	// ansiPattern matches ANSI escape sequences: colours and cursor movement (CSI), and titles and
+	// hyperlinks (OSC).
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+	// sectionPattern matches GitLab's collapsible section markers, e.g. "section_start:1700000000:step_script".
+	sectionPattern = regexp.MustCompile(`section_(start|end):\d+:([\w.-]+)(\[[^\]]*\])?`)
+
+	// timestampPattern matches the timestamps prefixed to each line by GitHub and by GitLab's
+	// timestamped logs, along with GitLab's stream marker.
+	timestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z (\d{2}[OE]\+? ?)?`)
+)
+
+// Normalise splits a raw job log into plain lines: timestamps, ANSI escape sequences, GitLab
+// section markers and GitHub group markers are removed, and lines overwritten through carriage
+// returns keep only their final text. Where the log has a GitLab script section, only its lines
+// are kept, leaving out the runner's preparation and cleanup.
+func Normalise(log string) []string {
+	var all, script []string
+
+	inScript := false
+
+	for _, line := range strings.Split(log, "\n") {
+		line = timestampPattern.ReplaceAllString(strings.TrimSuffix(line, "\r"), "")
+
+		for _, marker := range sectionPattern.FindAllStringSubmatch(line, -1) {
+			if marker[2] == scriptSection {
+				inScript = marker[1] == "start"
+			}
+		}
+
+		line = sectionPattern.ReplaceAllString(line, "")
+
+		// Progress bars and spinners redraw their line, so only the final redraw is kept
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+
+		line = strings.TrimRight(ansiPattern.ReplaceAllString(line, ""), " \t")
+
+		if strings.HasPrefix(line, "##[endgroup]") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "##[group]")
+
+		all = append(all, line)
+		if inScript {
+			script = append(script, line)
+		}
+	}
+
+	if len(script) > 0 {
+		return script
+	}
+
+	return all
+}
@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Artifact is a zip archive of files uploaded by a pipeline: a GitLab job's artifacts or a GitHub
+// workflow run artifact.
+type Artifact struct {
+	ID   int
+	Name string
+	// Job is the name of the job that uploaded the artifact, empty on GitHub where artifacts
+	// belong to the run as a whole.
+	Job  string
+	Size int64
+	// Expired artifacts can no longer be downloaded.
+	Expired bool
+}
+
+// FileName returns the artifact's name as a single path element, safe to join to a directory.
+// Names come from pipeline definitions, e.g. GitLab's parallel:matrix job names such as
+// "build: [linux/amd64]", so separators are replaced and names that aren't files fall back to the ID.
+func (a Artifact) FileName() string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '_'
+		}
+
+		return r
+	}, a.Name)
+
+	if name == "" || name == "." || name == ".." {
+		return "artifact-" + strconv.Itoa(a.ID)
+	}
+
+	return name
+}
+
+// ArtifactDownloader is implemented by clients of providers that store pipeline artifacts.
+type ArtifactDownloader interface {
+	Artifacts(id string, pid int) ([]Artifact, error)
+	// DownloadArtifact returns the contents of an artifact's zip archive, which must be closed.
+	DownloadArtifact(id string, artifact Artifact) (io.ReadCloser, error)
+}
@@ -0,0 +1,22 @@
+package gateway
+
+// Coverage is the code coverage reported by a pipeline's jobs, as percentages.
+type Coverage struct {
+	// Percent is the pipeline's overall coverage.
+	Percent float64
+	Jobs    []JobCoverage
+}
+
+type JobCoverage struct {
+	Name    string
+	Percent float64
+}
+
+// CoverageReporter is implemented by clients of providers that compute code coverage from job logs.
+type CoverageReporter interface {
+	// Coverage returns the pipeline's coverage, or nil if none of its jobs report any.
+	Coverage(id string, pid int) (*Coverage, error)
+
+	// DefaultBranch returns the project's default branch, against which coverage is compared.
+	DefaultBranch(id string) (string, error)
+}
@@ -0,0 +1,37 @@
+package gateway
+
+// PipelineTree is a pipeline along with the downstream pipelines it triggered, such as GitLab child
+// and multi-project pipelines or GitHub workflows triggered through workflow_run.
+type PipelineTree struct {
+	Pipeline Pipeline
+	// Bridge names what triggered the pipeline from upstream, empty for the root: the trigger job
+	// on GitLab, or the triggered workflow on GitHub.
+	Bridge     string
+	Downstream []PipelineTree
+}
+
+// DownstreamFinder is implemented by clients of providers whose pipelines can trigger others.
+type DownstreamFinder interface {
+	PipelineTree(id string, pid int) (*PipelineTree, error)
+}
+
+// Walk calls fn for every downstream pipeline of the tree, parents before their children.
+func (t *PipelineTree) Walk(fn func(*PipelineTree)) {
+	for i := range t.Downstream {
+		fn(&t.Downstream[i])
+		t.Downstream[i].Walk(fn)
+	}
+}
+
+// Pending returns the first downstream pipeline for which pending returns true, if any.
+func (t *PipelineTree) Pending(pending func(status string) bool) *Pipeline {
+	var found *Pipeline
+
+	t.Walk(func(child *PipelineTree) {
+		if found == nil && pending(child.Pipeline.Status) {
+			found = &child.Pipeline
+		}
+	})
+
+	return found
+}
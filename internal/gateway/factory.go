@@ -3,49 +3,82 @@ package gateway
 import (
 	"errors"
 	"strings"
+
+	"github.com/gregfurman/pipescope/internal/credentials"
 )
 
-func New(token string, t ProviderType) ( //nolint:ireturn
+func New(token string, t ProviderType, opts ...Option) ( //nolint:ireturn
 	Client,
 	error,
 ) {
 	switch t {
 	case GitHub:
-		return NewGitHubClient(token)
+		return NewGitHubClient(token, opts...)
 	case GitLab:
-		return NewGitLabClient(token)
+		return NewGitLabClient(token, opts...)
 	default:
 		return nil, errors.New("gateway client does not exist")
 	}
 }
 
-func NewFromToken(token string) ( //nolint:ireturn
+func NewFromToken(token string, opts ...Option) ( //nolint:ireturn
 	Client,
 	error,
 ) {
 	switch {
 	case isGitHubToken(token):
-		return NewGitHubClient(token)
+		return NewGitHubClient(token, opts...)
 	case isGitLabToken(token):
-		return NewGitLabClient(token)
+		return NewGitLabClient(token, opts...)
 
 	default:
 		return nil, errors.New("could not determine gateway client from access token")
 	}
 }
 
-func NewFromRemoteURL(token, remoteURL string) ( //nolint:ireturn
+// NewFromRemoteURL determines the provider from a git remote. If no token is given, one is
+// discovered from the credentials configured for the remote's host.
+func NewFromRemoteURL(token, remoteURL string, opts ...Option) ( //nolint:ireturn
 	Client,
 	error,
 ) {
+	var provider ProviderType
+
 	switch {
 	case strings.Contains(remoteURL, "github.com"):
-		return NewGitHubClient(token)
+		provider = GitHub
 	case strings.Contains(remoteURL, "gitlab.com"):
-		return NewGitLabClient(token)
+		provider = GitLab
 	default:
 		return nil, errors.New("could not determine gateway client from git repository's remote url")
 	}
+
+	if token == "" {
+		var authOpts []Option
+		token, authOpts = LookupToken(remoteURL, provider)
+		opts = append(opts, authOpts...)
+	}
+
+	return New(token, provider, opts...)
+}
+
+// LookupToken discovers an access token for a remote's host from the environment, the gh
+// and glab CLIs, ~/.netrc or git's credential helpers, returning an empty string if none is found.
+// The options needed to authenticate with the token are returned alongside it.
+func LookupToken(remoteURL string, provider ProviderType) (string, []Option) {
+	host, _ := ParseRemoteURL(remoteURL)
+
+	cred, _ := credentials.Lookup(host, string(provider))
+
+	switch cred.Source {
+	case credentials.SourceLogin:
+		// Tokens stored by `pipescope auth login` come from the OAuth device flow
+		return cred.Token, []Option{WithOAuthToken()}
+	case credentials.SourceCIJobToken:
+		return cred.Token, []Option{WithJobToken()}
+	}
+
+	return cred.Token, nil
 }
 
 func isGitHubToken(token string) bool {
@@ -53,6 +86,8 @@ func isGitHubToken(token string) bool {
 		GitHubPersonalAccessToken,
 		GitHubOAuth,
 		GitHubUserToServer,
+		GitHubServerToServer,
+		GitHubFineGrainedPersonalAccessToken,
 	} {
 		if strings.HasPrefix(token, prefix) {
 			return true
@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"testing"
+)
+
+func Test_NewFromToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+
+		want    ProviderType
+		wantErr bool
+	}{
+		{name: "GitHub personal access token", token: "ghp_abc", want: GitHub},
+		{name: "GitHub fine-grained personal access token", token: "github_pat_abc", want: GitHub},
+		{name: "GitHub installation token", token: "ghs_abc", want: GitHub},
+		{name: "GitLab personal access token", token: "glpat-abc", want: GitLab},
+		{name: "GitLab job token", token: "glcbt-abc", want: GitLab},
+		{name: "Unknown token", token: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			client, err := NewFromToken(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					ts.Error("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			var got ProviderType
+			switch client.(type) {
+			case *GitHubClient:
+				got = GitHub
+			case *GitLabClient:
+				got = GitLab
+			}
+
+			if got != tt.want {
+				ts.Errorf("expected %s client, got %T", tt.want, client)
+			}
+		})
+	}
+}
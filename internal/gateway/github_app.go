@@ -0,0 +1,282 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime is kept under GitHub's 10 minute maximum to allow for clock drift.
+	appJWTLifetime = 9 * time.Minute
+
+	// appTokenRefreshWindow is how long before expiry an installation token is replaced.
+	appTokenRefreshWindow = 5 * time.Minute
+)
+
+// GitHubApp holds the credentials used to authenticate as a GitHub App installation.
+type GitHubApp struct {
+	AppID int64
+
+	// InstallationID may be left unset, in which case the installation is discovered
+	// from the owner of each repository requested.
+	InstallationID int64
+
+	// PrivateKey is the app's PEM encoded RSA private key.
+	PrivateKey []byte
+}
+
+// installationAccessToken is an access token minted for an installation.
+type installationAccessToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// appTransport authenticates requests with a GitHub App installation access token,
+// minting a new one from a signed JWT whenever it nears expiry. Without a configured
+// installation, the installation on the owner of each requested repository is used.
+type appTransport struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	base           http.RoundTripper
+	baseURL        *url.URL
+	now            func() time.Time
+
+	mu sync.Mutex
+	// installations holds the installation discovered on each repository owner.
+	installations map[string]int64
+	// lastInstallation is used for requests outside of a repository.
+	lastInstallation int64
+	tokens           map[int64]installationAccessToken
+}
+
+func newAppTransport(app GitHubApp, base http.RoundTripper) (*appTransport, error) {
+	key, err := parseRSAPrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appTransport{
+		appID:          app.AppID,
+		key:            key,
+		base:           base,
+		now:            time.Now,
+		installationID: app.InstallationID,
+		installations:  make(map[string]int64),
+		tokens:         make(map[int64]installationAccessToken),
+	}, nil
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(req)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", "token "+token)
+
+	return t.base.RoundTrip(authenticated)
+}
+
+func (t *appTransport) installationToken(req *http.Request) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id, err := t.installation(req)
+	if err != nil {
+		return "", err
+	}
+
+	if cached, ok := t.tokens[id]; ok && t.now().Add(appTokenRefreshWindow).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	jwt, err := t.signJWT()
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	if err := t.appRequest(req, http.MethodPost, fmt.Sprintf("app/installations/%d/access_tokens", id), jwt, &token); err != nil {
+		return "", fmt.Errorf("failed to create GitHub App installation token: %w", err)
+	}
+
+	t.tokens[id] = installationAccessToken{token: token.Token, expiresAt: token.ExpiresAt}
+
+	return token.Token, nil
+}
+
+// resolved reports whether the installation requests outside of a repository are authenticated as
+// is known, either configured or discovered from an earlier request.
+func (t *appTransport) resolved() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.installationID != 0 || t.lastInstallation != 0
+}
+
+// installation returns the installation to authenticate a request as: the configured one, or
+// else the one on the owner of the requested repository, which is discovered once per owner.
+func (t *appTransport) installation(req *http.Request) (int64, error) {
+	if t.installationID != 0 {
+		return t.installationID, nil
+	}
+
+	owner, repo, ok := t.requestedRepo(req)
+	if !ok {
+		if t.lastInstallation == 0 {
+			return 0, errors.New("GitHub App installation ID is required for requests outside of a repository")
+		}
+
+		return t.lastInstallation, nil
+	}
+
+	// Owners are case-insensitive, as are the repository paths requested
+	id, ok := t.installations[strings.ToLower(owner)]
+	if !ok {
+		jwt, err := t.signJWT()
+		if err != nil {
+			return 0, err
+		}
+
+		if id, err = t.discoverInstallation(req, owner, repo, jwt); err != nil {
+			return 0, err
+		}
+
+		t.installations[strings.ToLower(owner)] = id
+	}
+
+	t.lastInstallation = id
+
+	return id, nil
+}
+
+// discoverInstallation finds the app's installation on the owner of a repository.
+func (t *appTransport) discoverInstallation(req *http.Request, owner, repo, jwt string) (int64, error) {
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := t.appRequest(req, http.MethodGet, fmt.Sprintf("repos/%s/%s/installation", owner, repo), jwt, &installation); err != nil {
+		return 0, fmt.Errorf("failed to find GitHub App installation for %s/%s: %w", owner, repo, err)
+	}
+
+	return installation.ID, nil
+}
+
+func (t *appTransport) requestedRepo(req *http.Request) (string, string, bool) {
+	path := strings.TrimPrefix(req.URL.Path, t.baseURL.Path)
+
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	if len(parts) < 3 || parts[0] != "repos" {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+// appRequest makes a request authenticated as the app itself, rather than an installation.
+func (t *appTransport) appRequest(orig *http.Request, method, path, jwt string, v any) error {
+	u, err := t.baseURL.Parse(path)
+	if err != nil {
+		return fmt.Errorf("failed to build GitHub App URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(orig.Context(), method, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build GitHub App request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return fmt.Errorf("GitHub App request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read GitHub App response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, bytes.TrimSpace(body))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode GitHub App response: %w", err)
+	}
+
+	return nil
+}
+
+// signJWT creates an RS256 signed JWT identifying the app, as described in
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+func (t *appTransport) signJWT() (string, error) {
+	now := t.now()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		// Backdated to allow for clock drift between us and GitHub
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to decode GitHub App private key: no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("failed to parse GitHub App private key: not an RSA key")
+	}
+
+	return rsaKey, nil
+}
@@ -0,0 +1,242 @@
+package gateway
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAppServer stands in for GitHub's API, verifying app JWTs against the public key and
+// issuing installation tokens that expire after the given lifetime.
+func newAppServer(t *testing.T, key *rsa.PrivateKey, lifetime time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var issued atomic.Int32
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/repos/gregfurman/pipescope/installation", func(w http.ResponseWriter, r *http.Request) {
+		verifyAppJWT(t, r, &key.PublicKey)
+		fmt.Fprint(w, `{"id": 42}`)
+	})
+
+	mux.HandleFunc("/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+
+		verifyAppJWT(t, r, &key.PublicKey)
+
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, n, time.Now().Add(lifetime).Format(time.RFC3339))
+	})
+
+	mux.HandleFunc("/repos/gregfurman/pipescope/actions/runs/8858984663", func(w http.ResponseWriter, r *http.Request) {
+		want := fmt.Sprintf("token ghs_%d", issued.Load())
+		if got := r.Header.Get("Authorization"); got != want {
+			t.Errorf("expected Authorization %q, got %q", want, got)
+		}
+
+		fmt.Fprint(w, `{"id": 8858984663, "status": "in_progress", "repository": {"full_name": "gregfurman/pipescope"}}`)
+	})
+
+	// Enterprise base URLs are rooted at /api/v3/
+	server := httptest.NewServer(http.StripPrefix("/api/v3", mux))
+	t.Cleanup(server.Close)
+
+	return server, &issued
+}
+
+func verifyAppJWT(t *testing.T, r *http.Request, pub *rsa.PublicKey) {
+	t.Helper()
+
+	jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		t.Fatalf("expected a bearer JWT, got %q", r.Header.Get("Authorization"))
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed JWT %q", jwt)
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("JWT signature verification failed: %s", err)
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("malformed JWT claims: %s", err)
+	}
+
+	if claims.Iss != "1234" || claims.Exp <= claims.Iat {
+		t.Errorf("unexpected JWT claims %+v", claims)
+	}
+}
+
+func Test_GitHubApp_Authentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tests := []struct {
+		name       string
+		lifetime   time.Duration
+		wantTokens int32
+	}{
+		{name: "Reuses a valid installation token", lifetime: time.Hour, wantTokens: 1},
+		{name: "Refreshes a token nearing expiry", lifetime: time.Minute, wantTokens: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			server, issued := newAppServer(ts, key, tt.lifetime)
+
+			client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"), WithGitHubApp(GitHubApp{AppID: 1234, PrivateKey: keyPEM}))
+			if err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				pipeline, err := client.GetPipeline("gregfurman/pipescope", 8858984663)
+				if err != nil {
+					ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+				}
+
+				if pipeline.Status != "in_progress" {
+					ts.Errorf("expected in_progress, got %s", pipeline.Status)
+				}
+			}
+
+			if got := issued.Load(); got != tt.wantTokens {
+				ts.Errorf("expected %d installation tokens to be issued, got %d", tt.wantTokens, got)
+			}
+		})
+	}
+}
+
+func Test_GitHubApp_InstallationPerOwner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	installations := map[string]int{"gregfurman/pipescope": 42, "acme/widgets": 43}
+
+	var discovered atomic.Int32
+
+	mux := http.NewServeMux()
+
+	for repo, id := range installations {
+		repo, id := repo, id
+
+		mux.HandleFunc("/repos/"+repo+"/installation", func(w http.ResponseWriter, r *http.Request) {
+			verifyAppJWT(t, r, &key.PublicKey)
+			discovered.Add(1)
+			fmt.Fprintf(w, `{"id": %d}`, id)
+		})
+
+		mux.HandleFunc(fmt.Sprintf("/app/installations/%d/access_tokens", id), func(w http.ResponseWriter, r *http.Request) {
+			verifyAppJWT(t, r, &key.PublicKey)
+			fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, id, time.Now().Add(time.Hour).Format(time.RFC3339))
+		})
+
+		mux.HandleFunc("/repos/"+repo+"/actions/runs/1", func(w http.ResponseWriter, r *http.Request) {
+			// Each owner's repositories are accessed with the token of the installation on that owner
+			if want := fmt.Sprintf("token ghs_%d", id); r.Header.Get("Authorization") != want {
+				t.Errorf("expected Authorization %q for %s, got %q", want, repo, r.Header.Get("Authorization"))
+			}
+
+			fmt.Fprint(w, `{"id": 1, "status": "completed"}`)
+		})
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/api/v3", mux))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"), WithGitHubApp(GitHubApp{AppID: 1234, PrivateKey: keyPEM}))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, repo := range []string{"gregfurman/pipescope", "acme/widgets"} {
+			if _, err := client.GetPipeline(repo, 1); err != nil {
+				t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+		}
+	}
+
+	if got := discovered.Load(); got != 2 {
+		t.Errorf("expected the installation of each owner to be discovered once, got %d discoveries", got)
+	}
+}
+
+func Test_Preflight_GitHubAppWithoutInstallation(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	// The installation is discovered from the first repository requested, which preflight has none of
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"), WithGitHubApp(GitHubApp{AppID: 1234, PrivateKey: keyPEM}))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if warnings, err := Preflight(client); err != nil || len(warnings) > 0 {
+		t.Errorf("expected preflight to pass, got %v and error %v", warnings, err)
+	}
+}
+
+func Test_parseRSAPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	if _, err := parseRSAPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})); err != nil {
+		t.Errorf("expected PKCS8 keys to be supported, got %s", err)
+	}
+
+	if _, err := parseRSAPrivateKey([]byte("not a key")); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/v61/github"
+)
+
+func (c *GitHubClient) Artifacts(id string, pid int) ([]Artifact, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &github.ListOptions{PerPage: maxPageSize}
+
+	var artifacts []Artifact
+
+	for {
+		page, resp, err := c.api.Actions.ListWorkflowRunArtifacts(context.Background(), owner, repo, int64(pid), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve artifacts from GitHub: %w", err)
+		}
+
+		for _, artifact := range page.Artifacts {
+			artifacts = append(artifacts, Artifact{
+				ID:      int(artifact.GetID()),
+				Name:    artifact.GetName(),
+				Size:    artifact.GetSizeInBytes(),
+				Expired: artifact.GetExpired(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return artifacts, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// DownloadArtifact follows GitHub's redirect to the artifact's archive, which is served from
+// short-lived, pre-signed storage URLs that must not be sent the access token.
+func (c *GitHubClient) DownloadArtifact(id string, artifact Artifact) (io.ReadCloser, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	u, _, err := c.api.Actions.DownloadArtifact(context.Background(), owner, repo, int64(artifact.ID), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact %s from GitHub: %w", artifact.Name, err)
+	}
+
+	archive, err := getPresigned(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact %s: %w", artifact.Name, err)
+	}
+
+	return archive, nil
+}
+
+// getPresigned fetches a pre-signed URL GitHub redirected to, without the client's credentials.
+func getPresigned(u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_GitHub_Artifacts(t *testing.T) {
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/actions/runs/1/artifacts":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"total_count": 2, "artifacts": [
+				{"id": 11, "name": "binaries", "size_in_bytes": 2048},
+				{"id": 12, "name": "coverage", "size_in_bytes": 512, "expired": true}
+			]}`))
+		case "/api/v3/repos/owner/repo/actions/artifacts/11/zip":
+			http.Redirect(w, r, server.URL+"/blob/11", http.StatusFound)
+		case "/blob/11":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("expected the access token not to be sent to artifact storage")
+			}
+
+			w.Write([]byte("zip"))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("token", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	artifacts, err := client.Artifacts("owner/repo", 1)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := []Artifact{
+		{ID: 11, Name: "binaries", Size: 2048},
+		{ID: 12, Name: "coverage", Size: 512, Expired: true},
+	}
+
+	if !reflect.DeepEqual(want, artifacts) {
+		t.Errorf("expected %v, got %v", want, artifacts)
+	}
+
+	archive, err := client.DownloadArtifact("owner/repo", artifacts[0])
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+	defer archive.Close()
+
+	if got, _ := io.ReadAll(archive); string(got) != "zip" {
+		t.Errorf("expected archive contents %q, got %q", "zip", got)
+	}
+}
@@ -0,0 +1,256 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// checkIDLength is the number of leading hex digits of a commit SHA that make up the ID of a
+// pipeline aggregated from checks, which is the most that fits in an int64. GitHub resolves
+// abbreviated SHAs, so the commit can be recovered from the ID alone.
+const checkIDLength = 15
+
+// checkPipelineID returns the ID of the pipeline aggregated from a commit's checks.
+func checkPipelineID(sha string) (int, error) {
+	if len(sha) < checkIDLength {
+		return 0, fmt.Errorf("commit SHA %s is too short to identify a pipeline", sha)
+	}
+
+	id, err := strconv.ParseInt(sha[:checkIDLength], 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed commit SHA %s: %w", sha, err)
+	}
+
+	return int(id), nil
+}
+
+func checkPipelineSHA(pid int) string {
+	return fmt.Sprintf("%0*x", checkIDLength, pid)
+}
+
+// commitChecks returns every check run and the combined commit status reported against a commit.
+func (c *GitHubClient) commitChecks(owner, repo, sha string) ([]*github.CheckRun, *github.CombinedStatus, error) {
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: maxPageSize}}
+
+	var runs []*github.CheckRun
+
+	for {
+		page, resp, err := c.api.Checks.ListCheckRunsForRef(context.Background(), owner, repo, sha, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to retrieve check runs from GitHub: %w", err)
+		}
+
+		runs = append(runs, page.CheckRuns...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	combined, _, err := c.api.Repositories.GetCombinedStatus(context.Background(), owner, repo, sha, &github.ListOptions{PerPage: maxPageSize})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve commit statuses from GitHub: %w", err)
+	}
+
+	return runs, combined, nil
+}
+
+// checkPipeline aggregates the check runs and commit statuses of a commit into a pipeline, with
+// each check as one of its jobs.
+func (c *GitHubClient) checkPipeline(id, sha string) (*Pipeline, []Job, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runs, combined, err := c.commitChecks(owner, repo, sha)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jobs := make([]Job, 0, len(runs)+len(combined.Statuses))
+	for _, run := range runs {
+		jobs = append(jobs, CheckRunToJob(run))
+	}
+
+	for _, status := range combined.Statuses {
+		jobs = append(jobs, CommitStatusToJob(status))
+	}
+
+	if len(jobs) == 0 {
+		return nil, nil, fmt.Errorf("%w for project %s@%s", ErrNoPipeline, id, sha)
+	}
+
+	// The requested SHA may be abbreviated, whereas the combined status reports it in full
+	if combined.GetSHA() != "" {
+		sha = combined.GetSHA()
+	}
+
+	pid, err := checkPipelineID(sha)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pipeline := &Pipeline{
+		ID:        pid,
+		ProjectID: owner + "/" + repo,
+		CommitSha: sha,
+		Status:    c.checkStatus(jobs),
+		URL:       c.webURL() + owner + "/" + repo + "/commit/" + sha,
+	}
+
+	for _, job := range jobs {
+		if !job.StartedAt.IsZero() && (pipeline.StartedAt.IsZero() || job.StartedAt.Before(pipeline.StartedAt)) {
+			pipeline.StartedAt = job.StartedAt
+		}
+
+		if job.FinishedAt.After(pipeline.FinishedAt) {
+			pipeline.FinishedAt = job.FinishedAt
+		}
+	}
+
+	pipeline.CreatedAt = pipeline.StartedAt
+
+	if c.IsStatusPending(pipeline.Status) {
+		pipeline.FinishedAt = time.Time{}
+	}
+
+	return pipeline, jobs, nil
+}
+
+// webURL returns the root of the web interface served alongside the client's API, with a trailing slash.
+func (c *GitHubClient) webURL() string {
+	u := *c.api.BaseURL
+	u.Host = strings.TrimPrefix(u.Host, "api.")
+	u.Path = strings.TrimSuffix(u.Path, "api/v3/")
+
+	return u.String()
+}
+
+// checkStatus returns the status of a pipeline made up of checks: pending while any check is,
+// failed if any check failed and otherwise cancelled if any check was.
+func (c *GitHubClient) checkStatus(jobs []Job) string {
+	status := "success"
+
+	for _, job := range jobs {
+		switch {
+		case c.IsStatusPending(job.Status):
+			return "in_progress"
+		case c.IsStatusFailed(job.Status):
+			status = "failure"
+		case job.Status == "cancelled" && status == "success":
+			status = "cancelled"
+		}
+	}
+
+	return status
+}
+
+func (c *GitHubClient) checkPipelineJobs(id string, pid int) ([]Job, error) {
+	_, jobs, err := c.checkPipeline(id, checkPipelineSHA(pid))
+
+	return jobs, err
+}
+
+// listCheckPipelines lists the pipelines aggregated from the checks of a ref's most recent commits,
+// skipping commits without any checks.
+func (c *GitHubClient) listCheckPipelines(id string, opts ListOptions) ([]Pipeline, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := &github.CommitsListOptions{
+		SHA:         opts.Ref,
+		ListOptions: github.ListOptions{PerPage: opts.perPage()},
+	}
+
+	status := withGitHubStatus(opts.Status)
+
+	var pipelines []Pipeline
+
+	for {
+		commits, resp, err := c.api.Repositories.ListCommits(context.Background(), owner, repo, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits from GitHub: %w", err)
+		}
+
+		for _, commit := range commits {
+			if opts.Limit > 0 && len(pipelines) == opts.Limit {
+				break
+			}
+
+			pipeline, _, err := c.checkPipeline(id, commit.GetSHA())
+			if errors.Is(err, ErrNoPipeline) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if status != "" && pipeline.Status != status {
+				continue
+			}
+
+			pipeline.Ref = opts.Ref
+			pipeline.Author = commit.GetAuthor().GetLogin()
+			pipelines = append(pipelines, *pipeline)
+		}
+
+		if !opts.more(len(pipelines), resp.NextPage) {
+			return pipelines, nil
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// CheckRunToJob converts a GitHub check run, such as one reported by a third-party CI app, into a Job
+// of the app's stage.
+func CheckRunToJob(run *github.CheckRun) Job {
+	return Job{
+		ID:         int(run.GetID()),
+		Name:       run.GetName(),
+		Stage:      run.GetApp().GetName(),
+		Status:     checkRunStatus(run),
+		URL:        run.GetHTMLURL(),
+		StartedAt:  run.GetStartedAt().Time,
+		FinishedAt: run.GetCompletedAt().Time,
+	}
+}
+
+// commitStatusStage is the stage of jobs converted from commit statuses.
+const commitStatusStage = "statuses"
+
+// CommitStatusToJob converts a GitHub commit status into a Job, linking to its target URL.
+func CommitStatusToJob(status *github.RepoStatus) Job {
+	job := Job{
+		ID:        int(status.GetID()),
+		Name:      status.GetContext(),
+		Stage:     commitStatusStage,
+		Status:    status.GetState(),
+		URL:       status.GetTargetURL(),
+		StartedAt: status.GetCreatedAt().Time,
+	}
+
+	if status.GetState() != "pending" {
+		job.FinishedAt = status.GetUpdatedAt().Time
+	}
+
+	return job
+}
+
+func checkRunStatus(run *github.CheckRun) string {
+	if run.GetStatus() == "completed" && run.Conclusion != nil {
+		return run.GetConclusion()
+	}
+
+	return run.GetStatus()
+}
@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_GitHub_CheckPipeline(t *testing.T) {
+	const sha = "928e2df1e3a4b5c6d7e8f90123456789abcdef01"
+
+	responses := map[string]string{
+		"/api/v3/repos/owner/repo/commits/" + sha + "/check-runs": `{"total_count": 1, "check_runs": [
+			{"id": 11, "name": "build", "status": "completed", "conclusion": "success", "html_url": "https://github.example.com/owner/repo/runs/11",
+			 "started_at": "2024-05-01T12:00:00Z", "completed_at": "2024-05-01T12:05:00Z", "app": {"name": "Buildkite"}}
+		]}`,
+		"/api/v3/repos/owner/repo/commits/" + sha + "/status": `{"sha": "` + sha + `", "state": "failure", "statuses": [
+			{"id": 21, "context": "ci/circleci: test", "state": "failure", "target_url": "https://circleci.com/gh/owner/repo/21",
+			 "created_at": "2024-05-01T11:59:00Z", "updated_at": "2024-05-01T12:07:00Z"}
+		]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Pipelines are fetched by their ID through an abbreviated SHA
+		path := strings.Replace(r.URL.Path, "/commits/"+sha[:checkIDLength]+"/", "/commits/"+sha+"/", 1)
+
+		body, ok := responses[path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"), WithGitHubChecks())
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	pipeline, err := client.GetPipelineBySha("owner/repo", sha)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := Pipeline{
+		ID:         0x928e2df1e3a4b5c,
+		ProjectID:  "owner/repo",
+		CommitSha:  sha,
+		Status:     "failure",
+		URL:        server.URL + "/owner/repo/commit/" + sha,
+		CreatedAt:  time.Date(2024, 5, 1, 11, 59, 0, 0, time.UTC),
+		StartedAt:  time.Date(2024, 5, 1, 11, 59, 0, 0, time.UTC),
+		FinishedAt: time.Date(2024, 5, 1, 12, 7, 0, 0, time.UTC),
+	}
+
+	if *pipeline != want {
+		t.Errorf("expected %v, got %v", want, *pipeline)
+	}
+
+	refreshed, err := client.GetPipeline("owner/repo", pipeline.ID)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if *refreshed != want {
+		t.Errorf("expected %v, got %v", want, *refreshed)
+	}
+
+	jobs, err := client.GetPipelineJobs("owner/repo", pipeline.ID)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	wantJobs := []Job{
+		{
+			ID: 11, Name: "build", Stage: "Buildkite", Status: "success", URL: "https://github.example.com/owner/repo/runs/11",
+			StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), FinishedAt: time.Date(2024, 5, 1, 12, 5, 0, 0, time.UTC),
+		},
+		{
+			ID: 21, Name: "ci/circleci: test", Stage: "statuses", Status: "failure", URL: "https://circleci.com/gh/owner/repo/21",
+			StartedAt: time.Date(2024, 5, 1, 11, 59, 0, 0, time.UTC), FinishedAt: time.Date(2024, 5, 1, 12, 7, 0, 0, time.UTC),
+		},
+	}
+
+	if !reflect.DeepEqual(wantJobs, jobs) {
+		t.Errorf("expected %v, got %v", wantJobs, jobs)
+	}
+}
+
+func Test_GitHub_CheckStatus(ts *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		expected string
+	}{
+		{name: "all succeeded", statuses: []string{"success", "neutral", "skipped"}, expected: "success"},
+		{name: "pending status", statuses: []string{"failure", "pending"}, expected: "in_progress"},
+		{name: "queued check run", statuses: []string{"success", "queued"}, expected: "in_progress"},
+		{name: "errored status", statuses: []string{"success", "error"}, expected: "failure"},
+		{name: "failure outranks cancellation", statuses: []string{"cancelled", "timed_out"}, expected: "failure"},
+		{name: "cancelled", statuses: []string{"success", "cancelled"}, expected: "cancelled"},
+	}
+
+	client := &GitHubClient{}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			jobs := make([]Job, 0, len(test.statuses))
+			for _, status := range test.statuses {
+				jobs = append(jobs, Job{Status: status})
+			}
+
+			if got := client.checkStatus(jobs); got != test.expected {
+				t.Errorf("expected status %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
@@ -4,27 +4,76 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v61/github"
 )
 
 type GitHubClient struct {
-	api *github.Client
+	api       *github.Client
+	rateLimit *rateLimitTransport
+	// installation is set when authenticating as a GitHub App installation, whose tokens
+	// cannot access user endpoints.
+	installation bool
+	// anonymous is set when the client has no credentials, so can only read public repositories.
+	anonymous bool
+	// app authenticates requests as a GitHub App installation, if configured.
+	app *appTransport
+	// useChecks aggregates pipelines from check runs and commit statuses rather than workflow runs.
+	useChecks bool
+	// definitions caches the workflow_run triggers of workflow definitions by path and commit.
+	definitions sync.Map
 }
 
-func NewGitHubClient(token string) (*GitHubClient, error) {
-	client := github.NewClient(nil).WithAuthToken(token)
+func NewGitHubClient(token string, opts ...Option) (*GitHubClient, error) {
+	o := newOptions(opts)
+
+	var (
+		transport    = observe(http.DefaultTransport, GitHub, o.observer)
+		rateLimit    = newRateLimitTransport(transport)
+		client       = github.NewClient(&http.Client{Transport: rateLimit}).WithAuthToken(token)
+		appTransport *appTransport
+		err          error
+	)
+
+	if o.githubApp != nil {
+		appTransport, err = newAppTransport(*o.githubApp, transport)
+		if err != nil {
+			return nil, err
+		}
+
+		rateLimit.next = appTransport
+		client = github.NewClient(&http.Client{Transport: rateLimit})
+	}
+
+	if o.baseURL != "" {
+		client, err = client.WithEnterpriseURLs(o.baseURL, o.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create new GitHub client: %w", err)
+		}
+	}
+
+	if appTransport != nil {
+		appTransport.baseURL = client.BaseURL
+	}
 
 	return &GitHubClient{
-		api: client,
+		api:          client,
+		rateLimit:    rateLimit,
+		installation: appTransport != nil || strings.HasPrefix(token, GitHubServerToServer),
+		anonymous:    appTransport == nil && token == "",
+		app:          appTransport,
+		useChecks:    o.githubChecks,
 	}, nil
 }
 
 func (c *GitHubClient) GetProjectID(path string) (int, error) {
-	owner, repo, ok := strings.Cut(strings.TrimPrefix(strings.TrimSuffix(path, ".git"), "git@github.com:"), "/")
-	if !ok {
-		return 0, fmt.Errorf("malformed repository path: 'owner' and 'repo' could not be extracted from %s", path)
+	owner, repo, err := splitRepoPath(path)
+	if err != nil {
+		return 0, err
 	}
 
 	project, _, err := c.api.Repositories.Get(context.Background(), owner, repo)
@@ -36,9 +85,15 @@ func (c *GitHubClient) GetProjectID(path string) (int, error) {
 }
 
 func (c *GitHubClient) GetPipelineBySha(id, sha string) (*Pipeline, error) {
-	owner, repo, ok := strings.Cut(strings.TrimPrefix(strings.TrimSuffix(id, ".git"), "git@github.com:"), "/")
-	if !ok {
-		return nil, fmt.Errorf("malformed repository path: 'owner' and 'repo' could not be extracted from %s", id)
+	if c.useChecks {
+		pipeline, _, err := c.checkPipeline(id, sha)
+
+		return pipeline, err
+	}
+
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
 	}
 
 	runs, _, err := c.api.Actions.ListRepositoryWorkflowRuns(context.Background(), owner, repo, &github.ListWorkflowRunsOptions{
@@ -50,16 +105,22 @@ func (c *GitHubClient) GetPipelineBySha(id, sha string) (*Pipeline, error) {
 	}
 
 	if len(runs.WorkflowRuns) == 0 {
-		return nil, fmt.Errorf("no pipelines found for project %s@%s", id, sha)
+		return nil, fmt.Errorf("%w for project %s@%s", ErrNoPipeline, id, sha)
 	}
 
-	return workflowToPipeline(runs.WorkflowRuns[0]), nil
+	return WorkflowRunToPipeline(runs.WorkflowRuns[0]), nil
 }
 
 func (c *GitHubClient) GetPipeline(id string, pid int) (*Pipeline, error) {
-	owner, repo, ok := strings.Cut(strings.TrimPrefix(strings.TrimSuffix(id, ".git"), "git@github.com:"), "/")
-	if !ok {
-		return nil, fmt.Errorf("malformed repository path: 'owner' and 'repo' could not be extracted from %s", id)
+	if c.useChecks {
+		pipeline, _, err := c.checkPipeline(id, checkPipelineSHA(pid))
+
+		return pipeline, err
+	}
+
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
 	}
 
 	workflow, _, err := c.api.Actions.GetWorkflowRunByID(context.Background(), owner, repo, int64(pid))
@@ -71,27 +132,233 @@ func (c *GitHubClient) GetPipeline(id string, pid int) (*Pipeline, error) {
 		return nil, errors.New("no workflows found")
 	}
 
-	return workflowToPipeline(workflow), nil
+	return WorkflowRunToPipeline(workflow), nil
+}
+
+func (c *GitHubClient) ListPipelines(id string, opts ListOptions) ([]Pipeline, error) {
+	if c.useChecks {
+		return c.listCheckPipelines(id, opts)
+	}
+
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := &github.ListWorkflowRunsOptions{
+		Branch:      opts.Ref,
+		Status:      withGitHubStatus(opts.Status),
+		ListOptions: github.ListOptions{PerPage: opts.perPage()},
+	}
+
+	var pipelines []Pipeline
+
+	// Listed runs are complete, so details needn't be fetched
+	for {
+		runs, resp, err := c.api.Actions.ListRepositoryWorkflowRuns(context.Background(), owner, repo, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pipelines from GitHub: %w", err)
+		}
+
+		for _, run := range runs.WorkflowRuns {
+			if opts.Limit > 0 && len(pipelines) == opts.Limit {
+				break
+			}
+
+			pipelines = append(pipelines, *WorkflowRunToPipeline(run))
+		}
+
+		if !opts.more(len(pipelines), resp.NextPage) {
+			return pipelines, nil
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// withGitHubStatus translates GitLab's status names into their GitHub equivalents, so that
+// pipelines of either provider can be filtered alike.
+func withGitHubStatus(status string) string {
+	switch status {
+	case "failed":
+		return "failure"
+	case "running":
+		return "in_progress"
+	case "canceled":
+		return "cancelled"
+	case "pending":
+		return "queued"
+	default:
+		return status
+	}
+}
+
+func (c *GitHubClient) GetPipelineJobs(id string, pid int) ([]Job, error) {
+	if c.useChecks {
+		return c.checkPipelineJobs(id, pid)
+	}
+
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &github.ListWorkflowJobsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	var jobs []Job
+
+	for {
+		page, resp, err := c.api.Actions.ListWorkflowJobs(context.Background(), owner, repo, int64(pid), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve jobs from GitHub: %w", err)
+		}
+
+		for _, job := range page.Jobs {
+			jobs = append(jobs, WorkflowJobToJob(job))
+		}
+
+		if resp.NextPage == 0 {
+			return jobs, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// RateLimit returns the API quota reported by GitHub's most recent response.
+func (c *GitHubClient) RateLimit() (RateLimit, bool) {
+	if c.rateLimit == nil {
+		return RateLimit{}, false
+	}
+
+	return c.rateLimit.RateLimit()
 }
 
-func workflowToPipeline(wf *github.WorkflowRun) *Pipeline {
+// ValidateToken checks the token against the authenticated user, reading classic token scopes
+// from the X-OAuth-Scopes header. Installation tokens are instead checked by listing the
+// repositories the installation can access. Anonymous clients have no token to check, nor do
+// GitHub Apps whose installation is yet to be discovered from a requested repository.
+func (c *GitHubClient) ValidateToken() (*TokenInfo, error) {
+	if c.anonymous || (c.app != nil && !c.app.resolved()) {
+		return nil, nil
+	}
+
+	info := &TokenInfo{Provider: GitHub}
+
+	var (
+		resp *github.Response
+		err  error
+	)
+
+	if c.installation {
+		_, resp, err = c.api.Apps.ListRepos(context.Background(), &github.ListOptions{PerPage: 1})
+	} else {
+		var user *github.User
+		user, resp, err = c.api.Users.Get(context.Background(), "")
+		info.User = user.GetLogin()
+	}
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w by GitHub (401 Unauthorized)", ErrInvalidToken)
+		}
+
+		return nil, fmt.Errorf("failed to validate token with GitHub: %w", err)
+	}
+
+	if values := resp.Header.Values("X-OAuth-Scopes"); len(values) > 0 {
+		info.Scopes = []string{}
+
+		for _, scope := range strings.Split(values[0], ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+
+	if expiry := resp.Header.Get("GitHub-Authentication-Token-Expiration"); expiry != "" {
+		for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+			if t, err := time.Parse(layout, expiry); err == nil {
+				info.ExpiresAt = t
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// WorkflowRunToPipeline converts a GitHub Actions workflow run, as returned by the API or delivered
+// in workflow_run webhooks, into a Pipeline.
+func WorkflowRunToPipeline(wf *github.WorkflowRun) *Pipeline {
 	status := wf.GetStatus()
 	if status == "completed" && wf.Conclusion != nil {
 		status = wf.GetConclusion()
 	}
 
-	return &Pipeline{
+	pipeline := &Pipeline{
 		Status:    status,
 		ID:        int(wf.GetID()),
 		ProjectID: wf.GetRepository().GetFullName(),
 		URL:       wf.GetHTMLURL(),
 		CommitSha: wf.GetHeadSHA(),
+		Ref:       wf.GetHeadBranch(),
+		Author:    wf.GetActor().GetLogin(),
+		CreatedAt: wf.GetCreatedAt().Time,
+		StartedAt: wf.GetRunStartedAt().Time,
+	}
+
+	// GitHub has no explicit completion timestamp, but a completed run is no longer updated.
+	if wf.GetStatus() == "completed" {
+		pipeline.FinishedAt = wf.GetUpdatedAt().Time
+	}
+
+	return pipeline
+}
+
+// WorkflowJobToJob converts a GitHub Actions workflow job into a Job.
+func WorkflowJobToJob(job *github.WorkflowJob) Job {
+	status := job.GetStatus()
+	if status == "completed" && job.Conclusion != nil {
+		status = job.GetConclusion()
+	}
+
+	return Job{
+		ID:         int(job.GetID()),
+		Name:       job.GetName(),
+		Stage:      job.GetWorkflowName(),
+		Status:     status,
+		URL:        job.GetHTMLURL(),
+		StartedAt:  job.GetStartedAt().Time,
+		FinishedAt: job.GetCompletedAt().Time,
 	}
 }
 
+// splitRepoPath extracts the owner and repository name from a GitHub remote URL or "owner/repo" path.
+func splitRepoPath(path string) (string, string, error) {
+	_, repoPath := ParseRemoteURL(path)
+
+	owner, repo, ok := strings.Cut(repoPath, "/")
+	if !ok {
+		return "", "", fmt.Errorf("malformed repository path: 'owner' and 'repo' could not be extracted from %s", path)
+	}
+
+	return owner, repo, nil
+}
+
 func (*GitHubClient) IsStatusPending(status string) bool {
 	switch status {
-	case "queued", "in_progress":
+	// Commit statuses are pending until they complete
+	case "queued", "in_progress", "requested", "waiting", "pending":
+		return true
+	}
+
+	return false
+}
+
+func (*GitHubClient) IsStatusFailed(status string) bool {
+	switch status {
+	case "failure", "timed_out", "startup_failure", "action_required", "error":
 		return true
 	}
 
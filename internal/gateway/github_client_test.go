@@ -3,7 +3,9 @@ package gateway
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v61/github"
 )
@@ -97,6 +99,7 @@ func Test_GitHub_GetPipelineBySha(t *testing.T) {
 				ID:        8858984663,
 				ProjectID: "gregfurman/pipescope",
 				CommitSha: "23ebbb3b14c9a026199474d2931bdc55863dfffc",
+				Ref:       "test-workflow",
 				Status:    "in_progress",
 				URL:       "https://github.com/gregfurman/pipescope/actions/runs/8858984663",
 			},
@@ -124,6 +127,7 @@ func Test_GitHub_GetPipelineBySha(t *testing.T) {
 				ID:        8858984663,
 				ProjectID: "gregfurman/pipescope",
 				CommitSha: "23ebbb3b14c9a026199474d2931bdc55863dfffc",
+				Ref:       "test-workflow",
 				Status:    "failure",
 				URL:       "https://github.com/gregfurman/pipescope/actions/runs/8858984663",
 			},
@@ -198,6 +202,7 @@ func Test_GitHub_GetPipeline(t *testing.T) {
 				ID:        8858984663,
 				ProjectID: "gregfurman/pipescope",
 				CommitSha: "23ebbb3b14c9a026199474d2931bdc55863dfffc",
+				Ref:       "test-workflow",
 				Status:    "in_progress",
 				URL:       "https://github.com/gregfurman/pipescope/actions/runs/8858984663",
 			},
@@ -223,6 +228,7 @@ func Test_GitHub_GetPipeline(t *testing.T) {
 				ID:        8858984663,
 				ProjectID: "gregfurman/pipescope",
 				CommitSha: "23ebbb3b14c9a026199474d2931bdc55863dfffc",
+				Ref:       "test-workflow",
 				Status:    "failure",
 				URL:       "https://github.com/gregfurman/pipescope/actions/runs/8858984663",
 			},
@@ -264,3 +270,157 @@ func Test_GitHub_GetPipeline(t *testing.T) {
 	}
 
 }
+
+func Test_GitHub_GetPipelineJobs(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		mockedResponseBody string
+
+		path       string
+		workflowID int
+
+		want    []Job
+		wantErr bool
+	}{
+		{
+			name: "Successfully returns workflow jobs",
+			mockedResponseBody: `{"total_count": 2, "jobs": [
+				{
+					"id": 399444496,
+					"run_id": 8858984663,
+					"workflow_name": "CI",
+					"status": "completed",
+					"conclusion": "success",
+					"name": "build",
+					"html_url": "https://github.com/gregfurman/pipescope/actions/runs/8858984663/job/399444496"
+				},
+				{
+					"id": 399444497,
+					"run_id": 8858984663,
+					"workflow_name": "CI",
+					"status": "completed",
+					"conclusion": "failure",
+					"name": "test",
+					"html_url": "https://github.com/gregfurman/pipescope/actions/runs/8858984663/job/399444497"
+				}
+			]}`,
+			workflowID: 8858984663,
+			path:       "gregfurman/pipescope",
+			want: []Job{
+				{ID: 399444496, Name: "build", Stage: "CI", Status: "success", URL: "https://github.com/gregfurman/pipescope/actions/runs/8858984663/job/399444496"},
+				{ID: 399444497, Name: "test", Stage: "CI", Status: "failure", URL: "https://github.com/gregfurman/pipescope/actions/runs/8858984663/job/399444497"},
+			},
+		},
+		{
+			name:       "Fails due to malformed path",
+			workflowID: 8858984663,
+			path:       "incorrect path format",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			mockedAPI := github.NewClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(tt.mockedResponseBody)}})
+
+			client := GitHubClient{
+				api: mockedAPI,
+			}
+			got, err := client.GetPipelineJobs(tt.path, tt.workflowID)
+			if err != nil && !tt.wantErr {
+				ts.Errorf("unexpected error occurred. expected nil, got %s", err)
+				return
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					ts.Error("expected an error, got nil")
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+		})
+	}
+
+}
+
+func Test_GitHub_ListPipelines(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		mockedResponseBody string
+
+		path string
+
+		want    []Pipeline
+		wantErr bool
+	}{
+		{
+			name: "Successfully returns workflow runs",
+			mockedResponseBody: `{"total_count": 1, "workflow_runs": [
+				{
+					"id": 8858984663,
+					"head_branch": "main",
+					"head_sha": "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+					"status": "completed",
+					"conclusion": "success",
+					"html_url": "https://github.com/gregfurman/pipescope/actions/runs/8858984663",
+					"run_started_at": "2024-04-26T10:00:00Z",
+					"updated_at": "2024-04-26T10:05:00Z",
+					"repository": {"full_name": "gregfurman/pipescope"}
+				}
+			]}`,
+			path: "gregfurman/pipescope",
+			want: []Pipeline{
+				{
+					ID:         8858984663,
+					ProjectID:  "gregfurman/pipescope",
+					CommitSha:  "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+					Ref:        "main",
+					Status:     "success",
+					URL:        "https://github.com/gregfurman/pipescope/actions/runs/8858984663",
+					StartedAt:  time.Date(2024, 4, 26, 10, 0, 0, 0, time.UTC),
+					FinishedAt: time.Date(2024, 4, 26, 10, 5, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			name:    "Fails due to malformed path",
+			path:    "incorrect path format",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			mockedAPI := github.NewClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(tt.mockedResponseBody)}})
+
+			client := GitHubClient{
+				api: mockedAPI,
+			}
+			got, err := client.ListPipelines(tt.path, ListOptions{Ref: "main", Limit: 10})
+			if err != nil && !tt.wantErr {
+				ts.Errorf("unexpected error occurred. expected nil, got %s", err)
+				return
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					ts.Error("expected an error, got nil")
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+		})
+	}
+
+}
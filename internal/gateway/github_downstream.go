@@ -0,0 +1,206 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"gopkg.in/yaml.v3"
+)
+
+// workflowRunDelay is how long after a run completes the runs it triggers through workflow_run are
+// awaited, as GitHub creates them asynchronously and they may be skipped by branch filters.
+const workflowRunDelay = time.Minute
+
+// PipelineTree returns a workflow run along with the runs of every workflow triggered by its
+// completion through workflow_run, recursively, correlated by their head commit. A triggered
+// workflow yet to start is included as a queued pipeline for a short while after the run
+// completes. Reusable workflows run within the calling run, so are already among its jobs.
+func (c *GitHubClient) PipelineTree(id string, pid int) (*PipelineTree, error) {
+	if c.useChecks {
+		pipeline, err := c.GetPipeline(id, pid)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PipelineTree{Pipeline: *pipeline}, nil
+	}
+
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	run, _, err := c.api.Actions.GetWorkflowRunByID(context.Background(), owner, repo, int64(pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pipeline from GitHub: %w", err)
+	}
+
+	return c.workflowRunTree(owner, repo, run, "", maxDownstreamDepth)
+}
+
+func (c *GitHubClient) workflowRunTree(owner, repo string, run *github.WorkflowRun, trigger string, depth int) (*PipelineTree, error) {
+	tree := &PipelineTree{Pipeline: *WorkflowRunToPipeline(run), Bridge: trigger}
+	if depth == 0 {
+		return tree, nil
+	}
+
+	triggered, err := c.triggeredWorkflows(owner, repo, run)
+	if err != nil || len(triggered) == 0 {
+		return tree, err
+	}
+
+	runs, _, err := c.api.Actions.ListRepositoryWorkflowRuns(context.Background(), owner, repo, &github.ListWorkflowRunsOptions{
+		Event:       "workflow_run",
+		HeadSHA:     run.GetHeadSHA(),
+		ListOptions: github.ListOptions{PerPage: maxPageSize},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pipelines from GitHub: %w", err)
+	}
+
+	for _, workflow := range triggered {
+		// Runs are listed newest first, so the first match is the latest run of the workflow
+		i := slices.IndexFunc(runs.WorkflowRuns, func(r *github.WorkflowRun) bool {
+			return r.GetWorkflowID() == workflow.GetID() && !r.GetCreatedAt().Before(run.GetCreatedAt().Time)
+		})
+
+		if i < 0 {
+			if run.GetStatus() == "completed" && time.Since(run.GetUpdatedAt().Time) < workflowRunDelay {
+				tree.Downstream = append(tree.Downstream, PipelineTree{
+					Pipeline: Pipeline{ProjectID: owner + "/" + repo, CommitSha: run.GetHeadSHA(), Ref: run.GetHeadBranch(), Status: "queued"},
+					Bridge:   workflow.GetName(),
+				})
+			}
+
+			continue
+		}
+
+		child, err := c.workflowRunTree(owner, repo, runs.WorkflowRuns[i], workflow.GetName(), depth-1)
+		if err != nil {
+			return nil, err
+		}
+
+		tree.Downstream = append(tree.Downstream, *child)
+	}
+
+	return tree, nil
+}
+
+// triggeredWorkflows returns the active workflows whose definition on the default branch is
+// triggered by the completion of the run's workflow, as GitHub only runs workflow_run workflows
+// defined there, whichever branch the triggering run was for.
+func (c *GitHubClient) triggeredWorkflows(owner, repo string, run *github.WorkflowRun) ([]*github.Workflow, error) {
+	sha, err := c.defaultBranchHead(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	workflows, _, err := c.api.Actions.ListWorkflows(context.Background(), owner, repo, &github.ListOptions{PerPage: maxPageSize})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve workflows from GitHub: %w", err)
+	}
+
+	// Runs are named after their workflow unless it sets run-name, so the workflow is looked up by ID
+	var name string
+
+	for _, workflow := range workflows.Workflows {
+		if workflow.GetID() == run.GetWorkflowID() {
+			name = workflow.GetName()
+		}
+	}
+
+	var triggered []*github.Workflow
+
+	for _, workflow := range workflows.Workflows {
+		if workflow.GetState() != "active" || workflow.GetID() == run.GetWorkflowID() {
+			continue
+		}
+
+		triggers, err := c.workflowRunTriggers(owner, repo, workflow.GetPath(), sha)
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.Contains(triggers, name) {
+			triggered = append(triggered, workflow)
+		}
+	}
+
+	return triggered, nil
+}
+
+// defaultBranchHead returns the commit at the head of a repository's default branch.
+func (c *GitHubClient) defaultBranchHead(owner, repo string) (string, error) {
+	repository, _, err := c.api.Repositories.Get(context.Background(), owner, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve repository from GitHub: %w", err)
+	}
+
+	branch, _, err := c.api.Repositories.GetBranch(context.Background(), owner, repo, repository.GetDefaultBranch(), 1)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve default branch from GitHub: %w", err)
+	}
+
+	return branch.GetCommit().GetSHA(), nil
+}
+
+// workflowRunTriggers returns the names of the workflows whose runs trigger a workflow through
+// workflow_run, as defined at a commit. Definitions are cached, as they cannot change for a commit.
+func (c *GitHubClient) workflowRunTriggers(owner, repo, path, sha string) ([]string, error) {
+	key := owner + "/" + repo + "/" + path + "@" + sha
+	if triggers, ok := c.definitions.Load(key); ok {
+		return triggers.([]string), nil //nolint:forcetypeassert
+	}
+
+	file, _, resp, err := c.api.Repositories.GetContents(context.Background(), owner, repo, path, &github.RepositoryContentGetOptions{Ref: sha})
+	if err != nil {
+		// Workflows deleted since, or added after, the commit have no definition at it
+		if resp != nil && unavailable(resp.StatusCode) {
+			c.definitions.Store(key, []string(nil))
+
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to retrieve workflow definition from GitHub: %w", err)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode workflow definition: %w", err)
+	}
+
+	triggers := parseWorkflowRunTriggers([]byte(content))
+	c.definitions.Store(key, triggers)
+
+	return triggers, nil
+}
+
+// parseWorkflowRunTriggers returns the workflows listed under on.workflow_run.workflows in a
+// workflow definition. Malformed definitions have no triggers, as GitHub won't run them either.
+func parseWorkflowRunTriggers(definition []byte) []string {
+	var workflow struct {
+		On map[string]yaml.Node `yaml:"on"`
+	}
+
+	if err := yaml.Unmarshal(definition, &workflow); err != nil {
+		return nil
+	}
+
+	node, ok := workflow.On["workflow_run"]
+	if !ok {
+		return nil
+	}
+
+	var trigger struct {
+		Workflows []string `yaml:"workflows"`
+	}
+
+	if err := node.Decode(&trigger); err != nil {
+		return nil
+	}
+
+	return trigger.Workflows
+}
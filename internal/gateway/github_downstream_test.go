@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_GitHub_PipelineTree(t *testing.T) {
+	definition := func(yaml string) string {
+		return `{"type": "file", "encoding": "base64", "content": "` + base64.StdEncoding.EncodeToString([]byte(yaml)) + `"}`
+	}
+
+	responses := map[string]string{
+		"/api/v3/repos/owner/repo/actions/runs/1": `{"id": 1, "workflow_id": 100, "name": "CI", "status": "completed", "conclusion": "success", "head_sha": "928e2df", "created_at": "2024-05-01T12:00:00Z"}`,
+		"/api/v3/repos/owner/repo/actions/runs": `{"total_count": 2, "workflow_runs": [
+			{"id": 2, "workflow_id": 200, "name": "Deploy", "status": "in_progress", "head_sha": "928e2df", "created_at": "2024-05-01T12:10:00Z"},
+			{"id": 0, "workflow_id": 200, "name": "Deploy", "status": "completed", "conclusion": "success", "head_sha": "928e2df", "created_at": "2024-04-30T12:00:00Z"}
+		]}`,
+		"/api/v3/repos/owner/repo":               `{"default_branch": "main"}`,
+		"/api/v3/repos/owner/repo/branches/main": `{"name": "main", "commit": {"sha": "5f1c0a9"}}`,
+		"/api/v3/repos/owner/repo/actions/workflows": `{"total_count": 4, "workflows": [
+			{"id": 100, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active"},
+			{"id": 200, "name": "Deploy", "path": ".github/workflows/deploy.yml", "state": "active"},
+			{"id": 300, "name": "Lint", "path": ".github/workflows/lint.yml", "state": "active"},
+			{"id": 400, "name": "Old", "path": ".github/workflows/old.yml", "state": "disabled_manually"}
+		]}`,
+		"/api/v3/repos/owner/repo/contents/.github/workflows/deploy.yml": definition("on:\n  workflow_run:\n    workflows: [CI]\n    types: [completed]\n"),
+		"/api/v3/repos/owner/repo/contents/.github/workflows/lint.yml":   definition("on: [push, pull_request]\n"),
+		"/api/v3/repos/owner/repo/contents/.github/workflows/ci.yml":     definition("on: push\n"),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Workflow definitions are read from the default branch rather than the run's head commit
+		if strings.Contains(r.URL.Path, "/contents/") && r.URL.Query().Get("ref") != "5f1c0a9" {
+			t.Errorf("expected definition to be read at 5f1c0a9, got %q", r.URL.Query().Get("ref"))
+		}
+
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.PipelineTree("owner/repo", 1)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := []PipelineTree{
+		{
+			Pipeline: Pipeline{ID: 2, CommitSha: "928e2df", Status: "in_progress", CreatedAt: time.Date(2024, 5, 1, 12, 10, 0, 0, time.UTC)},
+			Bridge:   "Deploy",
+		},
+	}
+
+	if !reflect.DeepEqual(want, got.Downstream) {
+		t.Errorf("expected %v, got %v", want, got.Downstream)
+	}
+}
+
+func Test_ParseWorkflowRunTriggers(ts *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		expected   []string
+	}{
+		{name: "workflow_run trigger", definition: "on:\n  push:\n  workflow_run:\n    workflows: [CI, Build]\n", expected: []string{"CI", "Build"}},
+		{name: "single event", definition: "on: workflow_dispatch\n"},
+		{name: "event list", definition: "on: [push, workflow_run]\n"},
+		{name: "malformed", definition: "on: [\n"},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			if got := parseWorkflowRunTriggers([]byte(test.definition)); !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+)
+
+// JobLog follows GitHub's redirect to the log of an Actions job. Checks reported by other apps and
+// commit statuses have no logs on GitHub, so their logs are empty.
+func (c *GitHubClient) JobLog(id string, jobID int) (string, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return "", err
+	}
+
+	u, resp, err := c.api.Actions.GetWorkflowJobLogs(context.Background(), owner, repo, int64(jobID), 1)
+	if err != nil {
+		if resp != nil && unavailable(resp.StatusCode) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to retrieve log of job %d from GitHub: %w", jobID, err)
+	}
+
+	body, err := getPresigned(u)
+	if err != nil {
+		return "", fmt.Errorf("failed to download log of job %d: %w", jobID, err)
+	}
+	defer body.Close()
+
+	return readLogTail(body)
+}
@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_GitHub_JobLog(t *testing.T) {
+	const log = "2024-05-01T12:00:02.0000000Z ##[error]Process completed with exit code 1.\n"
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/actions/jobs/11/logs":
+			http.Redirect(w, r, server.URL+"/blob/11", http.StatusFound)
+		case "/api/v3/repos/owner/repo/actions/jobs/21/logs":
+			// Commit statuses aren't Actions jobs
+			http.NotFound(w, r)
+		case "/blob/11":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("expected the access token not to be sent to log storage")
+			}
+
+			w.Write([]byte(log))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("token", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.JobLog("owner/repo", 11)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got != log {
+		t.Errorf("expected log %q, got %q", log, got)
+	}
+
+	if got, err := client.JobLog("owner/repo", 21); err != nil || got != "" {
+		t.Errorf("expected an empty log, got %q and error %v", got, err)
+	}
+}
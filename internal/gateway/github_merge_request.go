@@ -0,0 +1,228 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v61/github"
+)
+
+func (c *GitHubClient) MergeRequestsByCommit(id, sha string) ([]MergeRequest, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, _, err := c.api.PullRequests.ListPullRequestsWithCommit(context.Background(), owner, repo, sha, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pull requests from GitHub: %w", err)
+	}
+
+	mrs := make([]MergeRequest, 0, len(prs))
+	for _, pr := range prs {
+		mrs = append(mrs, PullRequestToMergeRequest(pr))
+	}
+
+	return openFirst(mrs), nil
+}
+
+// OpenMergeRequest returns the open pull request from a branch of the repository. Forks usually
+// open theirs against their parent repository, so it is searched too when the repository has none.
+func (c *GitHubClient) OpenMergeRequest(id, branch string) (*MergeRequest, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	head := owner + ":" + branch
+
+	pr, err := c.openPullRequest(owner, repo, head)
+	if err != nil {
+		return nil, err
+	}
+
+	var project string
+
+	if pr == nil {
+		if pr, project, err = c.upstreamPullRequest(owner, repo, head); err != nil {
+			return nil, err
+		}
+	}
+
+	if pr == nil {
+		return nil, fmt.Errorf("%w for branch %s", ErrNoMergeRequest, branch)
+	}
+
+	mr := PullRequestToMergeRequest(pr)
+	mr.Project = project
+
+	return &mr, nil
+}
+
+// upstreamPullRequest returns the open pull request from a head of a fork to its parent repository,
+// along with the parent's "owner/repo" path, or nil if the repository isn't a fork or has none.
+func (c *GitHubClient) upstreamPullRequest(owner, repo, head string) (*github.PullRequest, string, error) {
+	repository, _, err := c.api.Repositories.Get(context.Background(), owner, repo)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve repository from GitHub: %w", err)
+	}
+
+	parent := repository.GetParent()
+	if !repository.GetFork() || parent == nil {
+		return nil, "", nil
+	}
+
+	pr, err := c.openPullRequest(parent.GetOwner().GetLogin(), parent.GetName(), head)
+
+	return pr, parent.GetFullName(), err
+}
+
+// openPullRequest returns the open pull request of a repository from a head, given as
+// "owner:branch", or nil if there is none.
+func (c *GitHubClient) openPullRequest(owner, repo, head string) (*github.PullRequest, error) {
+	prs, _, err := c.api.PullRequests.List(context.Background(), owner, repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  head,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pull requests from GitHub: %w", err)
+	}
+
+	if len(prs) == 0 {
+		return nil, nil
+	}
+
+	return prs[0], nil
+}
+
+// mergeRequestRepo returns the owner and name of the repository a pull request was opened in.
+func mergeRequestRepo(id string, mr *MergeRequest) (string, string, error) {
+	if mr.Project != "" {
+		id = mr.Project
+	}
+
+	return splitRepoPath(id)
+}
+
+// MergeRequestPipeline returns the latest workflow run triggered by a pull_request event for the
+// pull request's head commit, which runs in the repository the pull request was opened in.
+func (c *GitHubClient) MergeRequestPipeline(id string, mr *MergeRequest) (*Pipeline, error) {
+	owner, repo, err := mergeRequestRepo(id, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, _, err := c.api.Actions.ListRepositoryWorkflowRuns(context.Background(), owner, repo, &github.ListWorkflowRunsOptions{
+		Event:   "pull_request",
+		HeadSHA: mr.SHA,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pipelines from GitHub: %w", err)
+	}
+
+	if len(runs.WorkflowRuns) == 0 {
+		return nil, fmt.Errorf("%w for pull request #%d", ErrNoPipeline, mr.ID)
+	}
+
+	return WorkflowRunToPipeline(runs.WorkflowRuns[0]), nil
+}
+
+func (c *GitHubClient) MergeStatus(id string, mr *MergeRequest) (*MergeStatus, error) {
+	owner, repo, err := mergeRequestRepo(id, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := c.api.PullRequests.Get(context.Background(), owner, repo, mr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pull request from GitHub: %w", err)
+	}
+
+	// Mergeability is computed in the background, so is unknown until GitHub has done so
+	state := pr.GetMergeableState()
+	status := &MergeStatus{
+		Mergeable: pr.GetMergeable() && (state == "clean" || state == "unstable" || state == "has_hooks"),
+		Detail:    state,
+	}
+
+	required, resp, err := c.api.Repositories.GetRequiredStatusChecks(context.Background(), owner, repo, mr.TargetBranch)
+	if err != nil {
+		// Unprotected branches have no required checks, and reading protection needs admin access
+		if resp != nil && unavailable(resp.StatusCode) {
+			return status, nil
+		}
+
+		return nil, fmt.Errorf("failed to retrieve required checks from GitHub: %w", err)
+	}
+
+	checks, err := c.checks(owner, repo, pr.GetHead().GetSHA())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range requiredCheckNames(required) {
+		check, ok := checks[name]
+		if !ok {
+			check = Check{Name: name, Status: "expected"}
+		}
+
+		status.RequiredChecks = append(status.RequiredChecks, check)
+	}
+
+	return status, nil
+}
+
+// checks returns the check runs and commit statuses reported against a commit, keyed by name.
+func (c *GitHubClient) checks(owner, repo, sha string) (map[string]Check, error) {
+	runs, combined, err := c.commitChecks(owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make(map[string]Check)
+
+	for _, run := range runs {
+		checks[run.GetName()] = Check{Name: run.GetName(), Status: checkRunStatus(run), URL: run.GetHTMLURL()}
+	}
+
+	for _, s := range combined.Statuses {
+		checks[s.GetContext()] = Check{Name: s.GetContext(), Status: s.GetState(), URL: s.GetTargetURL()}
+	}
+
+	return checks, nil
+}
+
+func requiredCheckNames(required *github.RequiredStatusChecks) []string {
+	var names []string
+
+	if required.Checks != nil {
+		for _, check := range *required.Checks {
+			names = append(names, check.Context)
+		}
+	}
+
+	if len(names) == 0 && required.Contexts != nil {
+		names = append(names, *required.Contexts...)
+	}
+
+	return names
+}
+
+// PullRequestToMergeRequest converts a GitHub pull request into a MergeRequest. Merged pull
+// requests are closed on GitHub, but are given GitLab's merged state.
+func PullRequestToMergeRequest(pr *github.PullRequest) MergeRequest {
+	state := pr.GetState()
+	if pr.GetMerged() || pr.MergedAt != nil {
+		state = "merged"
+	}
+
+	return MergeRequest{
+		ID:           pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		State:        state,
+		SourceBranch: pr.GetHead().GetRef(),
+		TargetBranch: pr.GetBase().GetRef(),
+		SHA:          pr.GetHead().GetSHA(),
+		URL:          pr.GetHTMLURL(),
+	}
+}
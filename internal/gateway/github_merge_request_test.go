@@ -0,0 +1,172 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+)
+
+func Test_GitHub_MergeRequestsByCommit(t *testing.T) {
+	mockedAPI := github.NewClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(`[
+		{"number": 3, "title": "Old", "state": "closed", "merged_at": "2024-05-01T12:00:00Z", "html_url": "https://github.com/owner/repo/pull/3", "head": {"ref": "feature"}, "base": {"ref": "main"}},
+		{"number": 7, "title": "Add feature", "state": "open", "html_url": "https://github.com/owner/repo/pull/7", "head": {"ref": "feature"}, "base": {"ref": "main"}}
+	]`)}})
+
+	client := GitHubClient{api: mockedAPI}
+
+	got, err := client.MergeRequestsByCommit("owner/repo", "928e2df")
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := []MergeRequest{
+		{ID: 7, Title: "Add feature", State: "open", SourceBranch: "feature", TargetBranch: "main", URL: "https://github.com/owner/repo/pull/7"},
+		{ID: 3, Title: "Old", State: "merged", SourceBranch: "feature", TargetBranch: "main", URL: "https://github.com/owner/repo/pull/3"},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_GitHub_MergeStatus(t *testing.T) {
+	responses := map[string]string{
+		"/api/v3/repos/owner/repo/pulls/7":                                         `{"number": 7, "mergeable": true, "mergeable_state": "blocked", "head": {"sha": "928e2df"}}`,
+		"/api/v3/repos/owner/repo/branches/main/protection/required_status_checks": `{"checks": [{"context": "build"}, {"context": "ci/jenkins"}, {"context": "lint"}]}`,
+		"/api/v3/repos/owner/repo/commits/928e2df/check-runs":                      `{"total_count": 1, "check_runs": [{"name": "build", "status": "completed", "conclusion": "success", "html_url": "https://github.com/owner/repo/runs/1"}]}`,
+		"/api/v3/repos/owner/repo/commits/928e2df/status":                          `{"state": "pending", "statuses": [{"context": "ci/jenkins", "state": "pending", "target_url": "https://jenkins.example.com/1"}]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.MergeStatus("owner/repo", &MergeRequest{ID: 7, TargetBranch: "main"})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &MergeStatus{
+		Mergeable: false,
+		Detail:    "blocked",
+		RequiredChecks: []Check{
+			{Name: "build", Status: "success", URL: "https://github.com/owner/repo/runs/1"},
+			{Name: "ci/jenkins", Status: "pending", URL: "https://jenkins.example.com/1"},
+			{Name: "lint", Status: "expected"},
+		},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_GitHub_OpenMergeRequest(ts *testing.T) {
+	tests := []struct {
+		name       string
+		repository string
+		expected   *MergeRequest
+		err        error
+	}{
+		{
+			name:       "Pull request from a fork to its parent",
+			repository: `{"fork": true, "parent": {"name": "repo", "full_name": "upstream/repo", "owner": {"login": "upstream"}}}`,
+			expected:   &MergeRequest{ID: 9, Title: "Fix typo", State: "open", SourceBranch: "feature", TargetBranch: "main", SHA: "928e2df", Project: "upstream/repo"},
+		},
+		{
+			name:       "No pull request from a repository that isn't a fork",
+			repository: `{"fork": false}`,
+			err:        ErrNoMergeRequest,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			responses := map[string]string{
+				"/api/v3/repos/me/repo/pulls":       `[]`,
+				"/api/v3/repos/me/repo":             test.repository,
+				"/api/v3/repos/upstream/repo/pulls": `[{"number": 9, "title": "Fix typo", "state": "open", "head": {"ref": "feature", "sha": "928e2df"}, "base": {"ref": "main"}}]`,
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, ok := responses[r.URL.Path]
+				if !ok {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+					http.NotFound(w, r)
+
+					return
+				}
+
+				// Pull requests from forks are filtered by the fork's owner in the parent repository
+				if head := r.URL.Query().Get("head"); strings.HasSuffix(r.URL.Path, "/pulls") && head != "me:feature" {
+					t.Errorf("expected head me:feature, got %q", head)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+			if err != nil {
+				t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			got, err := client.OpenMergeRequest("https://github.com/me/repo.git", "feature")
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func Test_GitHub_MergeRequestPipeline_Fork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Workflows run for pull requests from forks in the repository they were opened in
+		if r.URL.Path != "/api/v3/repos/upstream/repo/actions/runs" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"total_count": 1, "workflow_runs": [{"id": 5, "status": "queued", "head_sha": "928e2df", "repository": {"full_name": "upstream/repo"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.MergeRequestPipeline("https://github.com/me/repo.git", &MergeRequest{ID: 9, SHA: "928e2df", Project: "upstream/repo"})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got.ID != 5 || got.ProjectID != "upstream/repo" {
+		t.Errorf("expected run 5 of upstream/repo, got run %d of %s", got.ID, got.ProjectID)
+	}
+}
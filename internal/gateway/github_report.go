@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// runnerAnnotationPath is the path of the annotations GitHub's runner adds to failed steps, e.g.
+// "Process completed with exit code 1.", which say nothing about which tests failed.
+const runnerAnnotationPath = ".github"
+
+// TestReport returns the failure annotations of the run's failed jobs, such as those added by test
+// reporting actions or problem matchers, grouped into suites by the annotated file. GitHub reports
+// no totals, so only failures are known.
+func (c *GitHubClient) TestReport(id string, pid int) (*TestReport, error) {
+	owner, repo, err := splitRepoPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := c.GetPipelineJobs(id, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TestReport{}
+
+	for _, job := range jobs {
+		// Commit statuses have no annotations, and their IDs may collide with those of check runs
+		if !c.IsStatusFailed(job.Status) || job.Stage == commitStatusStage {
+			continue
+		}
+
+		annotations, err := c.checkRunAnnotations(owner, repo, int64(job.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, annotation := range annotations {
+			if annotation.GetAnnotationLevel() != "failure" || annotation.GetPath() == runnerAnnotationPath {
+				continue
+			}
+
+			report.Failures = append(report.Failures, annotationToTestFailure(annotation))
+		}
+	}
+
+	return report, nil
+}
+
+// checkRunAnnotations returns every annotation of a check run. Actions jobs are check runs of the
+// same ID, and jobs that aren't check runs have none.
+func (c *GitHubClient) checkRunAnnotations(owner, repo string, id int64) ([]*github.CheckRunAnnotation, error) {
+	opts := &github.ListOptions{PerPage: maxPageSize}
+
+	var annotations []*github.CheckRunAnnotation
+
+	for {
+		page, resp, err := c.api.Checks.ListCheckRunAnnotations(context.Background(), owner, repo, id, opts)
+		if err != nil {
+			if resp != nil && unavailable(resp.StatusCode) {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("failed to retrieve check run annotations from GitHub: %w", err)
+		}
+
+		annotations = append(annotations, page...)
+
+		if resp.NextPage == 0 {
+			return annotations, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+func annotationToTestFailure(annotation *github.CheckRunAnnotation) TestFailure {
+	name := annotation.GetTitle()
+	if name == "" {
+		name = annotation.GetPath() + ":" + strconv.Itoa(annotation.GetStartLine())
+	}
+
+	return TestFailure{Suite: annotation.GetPath(), Name: name, Message: annotation.GetMessage()}
+}
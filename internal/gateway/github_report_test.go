@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_GitHub_TestReport(t *testing.T) {
+	responses := map[string]string{
+		"/api/v3/repos/owner/repo/actions/runs/1/jobs": `{"total_count": 2, "jobs": [
+			{"id": 11, "name": "build", "status": "completed", "conclusion": "success"},
+			{"id": 12, "name": "test", "status": "completed", "conclusion": "failure"}
+		]}`,
+		"/api/v3/repos/owner/repo/check-runs/12/annotations": `[
+			{"path": "pkg/foo/foo_test.go", "start_line": 12, "annotation_level": "failure", "title": "TestFoo", "message": "expected 1, got 2"},
+			{"path": "pkg/foo/bar_test.go", "start_line": 7, "annotation_level": "failure", "message": "undefined: bar"},
+			{"path": "pkg/foo/foo_test.go", "start_line": 3, "annotation_level": "warning", "title": "TestSlow", "message": "slow test"},
+			{"path": ".github", "start_line": 1, "annotation_level": "failure", "message": "Process completed with exit code 1."}
+		]`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.TestReport("owner/repo", 1)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &TestReport{
+		Failures: []TestFailure{
+			{Suite: "pkg/foo/foo_test.go", Name: "TestFoo", Message: "expected 1, got 2"},
+			{Suite: "pkg/foo/bar_test.go", Name: "pkg/foo/bar_test.go:7", Message: "undefined: bar"},
+		},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_GitHub_TestReport_PaginatedAnnotations(t *testing.T) {
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/api/v3/repos/owner/repo/actions/runs/1/jobs":
+			w.Write([]byte(`{"total_count": 1, "jobs": [{"id": 12, "name": "test", "status": "completed", "conclusion": "failure"}]}`))
+		case r.URL.Path == "/api/v3/repos/owner/repo/check-runs/12/annotations" && r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", `<`+server.URL+`/api/v3/repos/owner/repo/check-runs/12/annotations?page=2>; rel="next"`)
+			w.Write([]byte(`[{"path": "pkg/foo/foo_test.go", "annotation_level": "failure", "title": "TestFoo", "message": "expected 1, got 2"}]`))
+		case r.URL.Path == "/api/v3/repos/owner/repo/check-runs/12/annotations":
+			w.Write([]byte(`[{"path": "pkg/bar/bar_test.go", "annotation_level": "failure", "title": "TestBar", "message": "expected true"}]`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.TestReport("owner/repo", 1)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &TestReport{Failures: []TestFailure{
+		{Suite: "pkg/foo/foo_test.go", Name: "TestFoo", Message: "expected 1, got 2"},
+		{Suite: "pkg/bar/bar_test.go", Name: "TestBar", Message: "expected true"},
+	}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_GitHub_TestReport_CommitStatuses(t *testing.T) {
+	const sha = "928e2df1e3a4b5c6d7e8f90123456789abcdef01"
+
+	responses := map[string]string{
+		"/api/v3/repos/owner/repo/commits/" + sha + "/check-runs": `{"total_count": 1, "check_runs": [
+			{"id": 11, "name": "test", "status": "completed", "conclusion": "failure"}
+		]}`,
+		"/api/v3/repos/owner/repo/commits/" + sha + "/status": `{"sha": "` + sha + `", "state": "failure", "statuses": [
+			{"id": 21, "context": "ci/circleci: test", "state": "failure"}
+		]}`,
+		"/api/v3/repos/owner/repo/check-runs/11/annotations": `[
+			{"path": "pkg/foo/foo_test.go", "start_line": 12, "annotation_level": "failure", "title": "TestFoo", "message": "expected 1, got 2"}
+		]`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Commit statuses aren't check runs, so their annotations mustn't be requested
+		path := strings.Replace(r.URL.Path, "/commits/"+sha[:checkIDLength]+"/", "/commits/"+sha+"/", 1)
+
+		body, ok := responses[path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHubClient("", WithBaseURL(server.URL+"/"), WithGitHubChecks())
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := client.TestReport("owner/repo", 0x928e2df1e3a4b5c)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &TestReport{Failures: []TestFailure{{Suite: "pkg/foo/foo_test.go", Name: "TestFoo", Message: "expected 1, got 2"}}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
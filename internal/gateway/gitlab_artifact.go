@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Artifacts returns the artifacts archive of every job of the pipeline that uploaded one, named after
+// the job.
+func (c *GitLabClient) Artifacts(id string, pid int) ([]Artifact, error) {
+	opts := &gitlab.ListJobsOptions{
+		ListOptions:    gitlab.ListOptions{PerPage: maxPageSize},
+		IncludeRetried: gitlab.Ptr(false),
+	}
+
+	var artifacts []Artifact
+
+	for {
+		jobs, resp, err := c.api.Jobs.ListPipelineJobs(id, pid, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve pipeline jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			if job.ArtifactsFile.Filename == "" {
+				continue
+			}
+
+			artifacts = append(artifacts, Artifact{
+				ID:      job.ID,
+				Name:    job.Name,
+				Job:     job.Name,
+				Size:    int64(job.ArtifactsFile.Size),
+				Expired: job.ArtifactsExpireAt != nil && job.ArtifactsExpireAt.Before(time.Now()),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return artifacts, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// DownloadArtifact streams the job's artifacts archive, which may be too large to hold in memory.
+// Errors after the download has started, such as the archive not being found, are returned when
+// reading it.
+func (c *GitLabClient) DownloadArtifact(id string, artifact Artifact) (io.ReadCloser, error) {
+	req, err := c.api.NewRequest(http.MethodGet, fmt.Sprintf("projects/%s/jobs/%d/artifacts", gitlab.PathEscape(id), artifact.ID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifacts of job %s: %w", artifact.Job, err)
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		if _, err := c.api.Do(req, w); err != nil {
+			w.CloseWithError(fmt.Errorf("failed to download artifacts of job %s: %w", artifact.Job, err))
+
+			return
+		}
+
+		w.Close()
+	}()
+
+	return r, nil
+}
@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func Test_GitLab_Artifacts(t *testing.T) {
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(`[
+		{"id": 1, "name": "build", "artifacts_file": {"filename": "artifacts.zip", "size": 2048}},
+		{"id": 2, "name": "lint"},
+		{"id": 3, "name": "coverage", "artifacts_file": {"filename": "artifacts.zip", "size": 512}, "artifacts_expire_at": "2020-01-01T00:00:00Z"}
+	]`)}}))
+
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.Artifacts("1", 42)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := []Artifact{
+		{ID: 1, Name: "build", Job: "build", Size: 2048},
+		{ID: 3, Name: "coverage", Job: "coverage", Size: 512, Expired: true},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_GitLab_DownloadArtifact(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fproject/jobs/1/artifacts":
+			w.Write([]byte("zip"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitLabClient("", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	archive, err := client.DownloadArtifact("group/project", Artifact{ID: 1, Job: "build"})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+	defer archive.Close()
+
+	if got, err := io.ReadAll(archive); err != nil || string(got) != "zip" {
+		t.Errorf("expected archive contents %q, got %q and error %v", "zip", got, err)
+	}
+
+	missing, err := client.DownloadArtifact("group/project", Artifact{ID: 2, Job: "lint"})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+	defer missing.Close()
+
+	if _, err := io.ReadAll(missing); err == nil {
+		t.Error("expected an error reading a missing archive, got nil")
+	}
+}
+
+func Test_Artifact_FileName(ts *testing.T) {
+	tests := []struct {
+		name     string
+		artifact Artifact
+		expected string
+	}{
+		{name: "Plain name", artifact: Artifact{ID: 1, Name: "build"}, expected: "build"},
+		{name: "Matrix job name", artifact: Artifact{ID: 2, Name: "build: [linux/amd64]"}, expected: "build: [linux_amd64]"},
+		{name: "Parent directory", artifact: Artifact{ID: 3, Name: ".."}, expected: "artifact-3"},
+		{name: "Escaping path", artifact: Artifact{ID: 4, Name: "../../etc"}, expected: ".._.._etc"},
+		{name: "Windows separators", artifact: Artifact{ID: 5, Name: `..\build`}, expected: ".._build"},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			if got := test.artifact.FileName(); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -3,29 +3,60 @@ package gateway
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xanzy/go-gitlab"
 )
 
 type GitLabClient struct {
-	api *gitlab.Client
+	api       *gitlab.Client
+	rateLimit *rateLimitTransport
+	// jobToken is set when authenticating with a CI/CD job token, which can only access the
+	// project it was issued for.
+	jobToken bool
+	// anonymous is set when the client has no token, so can only read public projects.
+	anonymous bool
 }
 
-func NewGitLabClient(token string) (*GitLabClient, error) {
-	client, err := gitlab.NewClient(token)
+func NewGitLabClient(token string, opts ...Option) (*GitLabClient, error) {
+	o := newOptions(opts)
+
+	rateLimit := newRateLimitTransport(observe(http.DefaultTransport, GitLab, o.observer))
+
+	clientOpts := []gitlab.ClientOptionFunc{gitlab.WithHTTPClient(&http.Client{Transport: rateLimit})}
+	if o.baseURL != "" {
+		clientOpts = append(clientOpts, gitlab.WithBaseURL(o.baseURL))
+	}
+
+	// Job tokens and OAuth tokens need their own headers, whereas personal, project and group
+	// access tokens are sent as private tokens.
+	jobToken := o.jobToken || strings.HasPrefix(token, GitLabCICDJobToken)
+
+	newClient := gitlab.NewClient
+	if jobToken {
+		newClient = gitlab.NewJobClient
+	} else if o.oauthToken {
+		newClient = gitlab.NewOAuthClient
+	}
+
+	client, err := newClient(token, clientOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new GitLab client: %w", err)
 	}
 
 	return &GitLabClient{
-		api: client,
+		api:       client,
+		rateLimit: rateLimit,
+		jobToken:  jobToken,
+		anonymous: token == "",
 	}, nil
 }
 
 func (c *GitLabClient) GetProjectID(path string) (int, error) {
-	project, _, err := c.api.Projects.GetProject(strings.TrimPrefix(strings.TrimSuffix(path, ".git"), "git@gitlab.com:"), nil)
+	project, _, err := c.api.Projects.GetProject(projectPath(path), nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get project_id: %w", err)
 	}
@@ -34,7 +65,7 @@ func (c *GitLabClient) GetProjectID(path string) (int, error) {
 }
 
 func (c *GitLabClient) GetPipelineBySha(id string, sha string) (*Pipeline, error) {
-	pipelines, _, err := c.api.Pipelines.ListProjectPipelines(strings.TrimPrefix(strings.TrimSuffix(id, ".git"), "git@gitlab.com:"), &gitlab.ListProjectPipelinesOptions{
+	pipelines, _, err := c.api.Pipelines.ListProjectPipelines(projectPath(id), &gitlab.ListProjectPipelinesOptions{
 		SHA:     gitlab.Ptr(sha),
 		OrderBy: gitlab.Ptr("id"),
 	})
@@ -43,7 +74,7 @@ func (c *GitLabClient) GetPipelineBySha(id string, sha string) (*Pipeline, error
 	}
 
 	if len(pipelines) == 0 {
-		return nil, fmt.Errorf("no pipelines found for project %s@%s", id, sha)
+		return nil, fmt.Errorf("%w for project %s@%s", ErrNoPipeline, id, sha)
 	}
 
 	return &Pipeline{
@@ -52,6 +83,8 @@ func (c *GitLabClient) GetPipelineBySha(id string, sha string) (*Pipeline, error
 		ProjectID: strconv.Itoa(pipelines[0].ProjectID),
 		URL:       pipelines[0].WebURL,
 		CommitSha: pipelines[0].SHA,
+		Ref:       pipelines[0].Ref,
+		CreatedAt: timeOrZero(pipelines[0].CreatedAt),
 	}, nil
 }
 
@@ -66,14 +99,200 @@ func (c *GitLabClient) GetPipeline(id string, pid int) (*Pipeline, error) {
 	}
 
 	return &Pipeline{
-		Status:    pipeline.Status,
-		ID:        pipeline.ID,
-		ProjectID: strconv.Itoa(pipeline.ProjectID),
-		URL:       pipeline.WebURL,
-		CommitSha: pipeline.SHA,
+		Status:     pipeline.Status,
+		ID:         pipeline.ID,
+		ProjectID:  strconv.Itoa(pipeline.ProjectID),
+		URL:        pipeline.WebURL,
+		CommitSha:  pipeline.SHA,
+		Ref:        pipeline.Ref,
+		Author:     gitlabUsername(pipeline.User),
+		CreatedAt:  timeOrZero(pipeline.CreatedAt),
+		StartedAt:  timeOrZero(pipeline.StartedAt),
+		FinishedAt: timeOrZero(pipeline.FinishedAt),
 	}, nil
 }
 
+func (c *GitLabClient) ListPipelines(id string, opts ListOptions) ([]Pipeline, error) {
+	listOpts := &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: opts.perPage()},
+		OrderBy:     gitlab.Ptr("id"),
+	}
+
+	if opts.Ref != "" {
+		listOpts.Ref = gitlab.Ptr(opts.Ref)
+	}
+
+	if opts.Status != "" {
+		listOpts.Status = gitlab.Ptr(gitlab.BuildStateValue(opts.Status))
+	}
+
+	var pipelines []Pipeline
+
+	for {
+		infos, resp, err := c.api.Pipelines.ListProjectPipelines(projectPath(id), listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pipelines: %w", err)
+		}
+
+		for _, info := range infos {
+			if opts.Limit > 0 && len(pipelines) == opts.Limit {
+				break
+			}
+
+			pipeline, err := c.listedPipeline(info, opts.Details)
+			if err != nil {
+				return nil, err
+			}
+
+			pipelines = append(pipelines, pipeline)
+		}
+
+		if !opts.more(len(pipelines), resp.NextPage) {
+			return pipelines, nil
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// listedPipeline converts a listed pipeline, fetching its details if requested.
+func (c *GitLabClient) listedPipeline(info *gitlab.PipelineInfo, details bool) (Pipeline, error) {
+	if details {
+		pipeline, err := c.GetPipeline(strconv.Itoa(info.ProjectID), info.ID)
+		if err != nil {
+			return Pipeline{}, err
+		}
+
+		return *pipeline, nil
+	}
+
+	pipeline := Pipeline{
+		Status:    info.Status,
+		ID:        info.ID,
+		ProjectID: strconv.Itoa(info.ProjectID),
+		URL:       info.WebURL,
+		CommitSha: info.SHA,
+		Ref:       info.Ref,
+		CreatedAt: timeOrZero(info.CreatedAt),
+		// Listed pipelines lack timings, so their creation and last update are used instead
+		StartedAt: timeOrZero(info.CreatedAt),
+	}
+
+	if !c.IsStatusPending(info.Status) {
+		pipeline.FinishedAt = timeOrZero(info.UpdatedAt)
+	}
+
+	return pipeline, nil
+}
+
+func (c *GitLabClient) GetPipelineJobs(id string, pid int) ([]Job, error) {
+	opts := &gitlab.ListJobsOptions{
+		ListOptions:    gitlab.ListOptions{PerPage: 100},
+		IncludeRetried: gitlab.Ptr(false),
+	}
+
+	var jobs []Job
+
+	for {
+		page, resp, err := c.api.Jobs.ListPipelineJobs(id, pid, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve pipeline jobs: %w", err)
+		}
+
+		for _, job := range page {
+			jobs = append(jobs, Job{
+				ID:         job.ID,
+				Name:       job.Name,
+				Stage:      job.Stage,
+				Status:     job.Status,
+				URL:        job.WebURL,
+				StartedAt:  timeOrZero(job.StartedAt),
+				FinishedAt: timeOrZero(job.FinishedAt),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return jobs, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// RateLimit returns the API quota reported by GitLab's most recent response.
+func (c *GitLabClient) RateLimit() (RateLimit, bool) {
+	if c.rateLimit == nil {
+		return RateLimit{}, false
+	}
+
+	return c.rateLimit.RateLimit()
+}
+
+// ValidateToken reads a personal access token's scopes and expiry. OAuth tokens are not personal
+// access tokens, so are only checked against the authenticated user, and job tokens are not
+// checked at all as they cannot access either endpoint. Anonymous clients have no token to check.
+func (c *GitLabClient) ValidateToken() (*TokenInfo, error) {
+	if c.anonymous {
+		return nil, nil
+	}
+
+	info := &TokenInfo{Provider: GitLab}
+	if c.jobToken {
+		return info, nil
+	}
+
+	pat, _, err := c.api.PersonalAccessTokens.GetSinglePersonalAccessToken()
+	if err == nil {
+		info.Scopes = pat.Scopes
+		if pat.ExpiresAt != nil {
+			info.ExpiresAt = time.Time(*pat.ExpiresAt)
+		}
+
+		if pat.Revoked || !pat.Active {
+			return nil, fmt.Errorf("%w by GitLab: token %q is no longer active", ErrInvalidToken, pat.Name)
+		}
+
+		return info, nil
+	}
+
+	user, resp, err := c.api.Users.CurrentUser()
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w by GitLab (401 Unauthorized)", ErrInvalidToken)
+		}
+
+		return nil, fmt.Errorf("failed to validate token with GitLab: %w", err)
+	}
+
+	info.User = user.Username
+
+	return info, nil
+}
+
+// projectPath returns the "namespace/project" path of a GitLab remote URL. Numeric project
+// IDs and bare paths are returned as-is.
+func projectPath(id string) string {
+	_, p := ParseRemoteURL(id)
+
+	return p
+}
+
+func gitlabUsername(user *gitlab.BasicUser) string {
+	if user == nil {
+		return ""
+	}
+
+	return user.Username
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
+
 func (*GitLabClient) IsStatusPending(status string) bool {
 	switch gitlab.BuildStateValue(status) {
 	case gitlab.Created, gitlab.WaitingForResource, gitlab.Preparing, gitlab.Pending, gitlab.Running:
@@ -82,3 +301,7 @@ func (*GitLabClient) IsStatusPending(status string) bool {
 
 	return false
 }
+
+func (*GitLabClient) IsStatusFailed(status string) bool {
+	return gitlab.BuildStateValue(status) == gitlab.Failed
+}
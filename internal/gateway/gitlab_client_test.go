@@ -2,7 +2,10 @@ package gateway
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/xanzy/go-gitlab"
 )
@@ -93,11 +96,15 @@ func Test_GitLab_GetPipeline(t *testing.T) {
 			pipelineID: 46,
 			path:       "https://github.com/gregfurman/pipescope",
 			want: Pipeline{
-				ID:        46,
-				ProjectID: "1",
-				CommitSha: "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
-				Status:    "pending",
-				URL:       "https://example.com/gregfurman/pipescope/pipelines/46",
+				ID:         46,
+				ProjectID:  "1",
+				CommitSha:  "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+				Ref:        "main",
+				Status:     "pending",
+				URL:        "https://example.com/gregfurman/pipescope/pipelines/46",
+				Author:     "root",
+				CreatedAt:  time.Date(2016, 8, 11, 11, 28, 34, 85000000, time.UTC),
+				FinishedAt: time.Date(2016, 8, 11, 11, 32, 35, 145000000, time.UTC),
 			},
 		},
 		{
@@ -134,11 +141,15 @@ func Test_GitLab_GetPipeline(t *testing.T) {
 			pipelineID: 46,
 			path:       "gregfurman/pipescope",
 			want: Pipeline{
-				ID:        46,
-				ProjectID: "1",
-				CommitSha: "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
-				Status:    "failed",
-				URL:       "https://example.com/gregfurman/pipescope/pipelines/46",
+				ID:         46,
+				ProjectID:  "1",
+				CommitSha:  "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+				Ref:        "main",
+				Status:     "failed",
+				URL:        "https://example.com/gregfurman/pipescope/pipelines/46",
+				Author:     "root",
+				CreatedAt:  time.Date(2016, 8, 11, 11, 28, 34, 85000000, time.UTC),
+				FinishedAt: time.Date(2016, 8, 11, 11, 32, 35, 145000000, time.UTC),
 			},
 		},
 		{
@@ -214,8 +225,10 @@ func Test_GitLab_GetPipelineBySha(t *testing.T) {
 				ID:        47,
 				ProjectID: "1",
 				CommitSha: "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+				Ref:       "new-pipeline",
 				Status:    "pending",
 				URL:       "https://example.com/gregfurman/pipescope/pipelines/47",
+				CreatedAt: time.Date(2016, 8, 11, 11, 28, 34, 85000000, time.UTC),
 			},
 		},
 		{
@@ -254,8 +267,10 @@ func Test_GitLab_GetPipelineBySha(t *testing.T) {
 				ID:        48,
 				ProjectID: "1",
 				CommitSha: "eb94b618fb5865b26e80fdd8ae531b7a63ad851a",
+				Ref:       "new-pipeline",
 				Status:    "failed",
 				URL:       "https://example.com/gregfurman/pipescope/pipelines/48",
+				CreatedAt: time.Date(2016, 8, 12, 10, 6, 4, 561000000, time.UTC),
 			},
 		},
 		{
@@ -294,3 +309,263 @@ func Test_GitLab_GetPipelineBySha(t *testing.T) {
 	}
 
 }
+
+func Test_GitLab_GetPipelineJobs(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		mockedResponseBody string
+
+		path       string
+		pipelineID int
+
+		want    []Job
+		wantErr bool
+	}{
+		{
+			name: "Successfully returns pipeline jobs",
+			mockedResponseBody: `[
+				{
+					"id": 6,
+					"name": "rspec:other",
+					"stage": "test",
+					"status": "failed",
+					"started_at": "2015-12-24T17:54:24.921Z",
+					"finished_at": "2015-12-24T17:54:31.198Z",
+					"web_url": "https://example.com/foo/bar/-/jobs/6"
+				}
+			]`,
+			pipelineID: 46,
+			path:       "gregfurman/pipescope",
+			want: []Job{
+				{
+					ID:         6,
+					Name:       "rspec:other",
+					Stage:      "test",
+					Status:     "failed",
+					URL:        "https://example.com/foo/bar/-/jobs/6",
+					StartedAt:  time.Date(2015, 12, 24, 17, 54, 24, 921000000, time.UTC),
+					FinishedAt: time.Date(2015, 12, 24, 17, 54, 31, 198000000, time.UTC),
+				},
+			},
+		},
+		{
+			name:               "Fails due to malformed response",
+			mockedResponseBody: `{}`,
+			pipelineID:         46,
+			path:               "gregfurman/pipescope",
+			wantErr:            true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(tt.mockedResponseBody)}}))
+
+			client := GitLabClient{
+				api: mockedAPI,
+			}
+			got, err := client.GetPipelineJobs(tt.path, tt.pipelineID)
+			if err != nil && !tt.wantErr {
+				ts.Errorf("unexpected error occurred. expected nil, got %s", err)
+				return
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					ts.Error("expected an error, got nil")
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+		})
+	}
+
+}
+
+func Test_GitLab_ListPipelines(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		mockedResponseBody string
+
+		path string
+
+		want    []Pipeline
+		wantErr bool
+	}{
+		{
+			name: "Successfully returns pipelines",
+			mockedResponseBody: `[
+				{
+					"id": 48,
+					"project_id": 1,
+					"status": "success",
+					"ref": "main",
+					"sha": "eb94b618fb5865b26e80fdd8ae531b7a63ad851a",
+					"web_url": "https://example.com/gregfurman/pipescope/pipelines/48",
+					"created_at": "2016-08-12T10:06:04.561Z",
+					"updated_at": "2016-08-12T10:09:56.223Z"
+				},
+				{
+					"id": 49,
+					"project_id": 1,
+					"status": "running",
+					"ref": "main",
+					"sha": "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+					"web_url": "https://example.com/gregfurman/pipescope/pipelines/49",
+					"created_at": "2016-08-12T11:06:04.561Z",
+					"updated_at": "2016-08-12T11:09:56.223Z"
+				}
+			]`,
+			path: "gregfurman/pipescope",
+			want: []Pipeline{
+				{
+					ID:         48,
+					ProjectID:  "1",
+					CommitSha:  "eb94b618fb5865b26e80fdd8ae531b7a63ad851a",
+					Ref:        "main",
+					Status:     "success",
+					URL:        "https://example.com/gregfurman/pipescope/pipelines/48",
+					CreatedAt:  time.Date(2016, 8, 12, 10, 6, 4, 561000000, time.UTC),
+					StartedAt:  time.Date(2016, 8, 12, 10, 6, 4, 561000000, time.UTC),
+					FinishedAt: time.Date(2016, 8, 12, 10, 9, 56, 223000000, time.UTC),
+				},
+				{
+					ID:        49,
+					ProjectID: "1",
+					CommitSha: "a91957a858320c0e17f3a0eca7cfacbff50ea29a",
+					Ref:       "main",
+					Status:    "running",
+					URL:       "https://example.com/gregfurman/pipescope/pipelines/49",
+					CreatedAt: time.Date(2016, 8, 12, 11, 6, 4, 561000000, time.UTC),
+					StartedAt: time.Date(2016, 8, 12, 11, 6, 4, 561000000, time.UTC),
+				},
+			},
+		},
+		{
+			name:               "Fails due to malformed response",
+			mockedResponseBody: `{}`,
+			path:               "gregfurman/pipescope",
+			wantErr:            true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(tt.mockedResponseBody)}}))
+
+			client := GitLabClient{
+				api: mockedAPI,
+			}
+			got, err := client.ListPipelines(tt.path, ListOptions{Ref: "main", Limit: 10})
+			if err != nil && !tt.wantErr {
+				ts.Errorf("unexpected error occurred. expected nil, got %s", err)
+				return
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					ts.Error("expected an error, got nil")
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+		})
+	}
+
+}
+
+func Test_GitLab_ListPipelines_Paginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("status") != "failed" || r.URL.Query().Get("per_page") != "3" {
+			t.Errorf("expected status filter and page size to be sent, got %s", r.URL.RawQuery)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"id": 3, "status": "failed"}, {"id": 4, "status": "failed"}]`))
+			return
+		}
+
+		w.Header().Set("X-Next-Page", "2")
+		w.Write([]byte(`[{"id": 1, "status": "failed"}, {"id": 2, "status": "failed"}]`))
+	}))
+	defer server.Close()
+
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.ListPipelines("gregfurman/pipescope", ListOptions{Status: "failed", Limit: 3})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	ids := make([]int, 0, len(got))
+	for _, p := range got {
+		ids = append(ids, p.ID)
+	}
+
+	if !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Errorf("expected pipelines 1 to 3 across pages, got %v", ids)
+	}
+}
+
+func Test_NewGitLabClient_AuthHeader(ts *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		opts   []Option
+		header string
+		value  string
+	}{
+		{name: "Personal access token", token: "glpat-abc", header: "PRIVATE-TOKEN", value: "glpat-abc"},
+		{name: "Unprefixed token", token: "abc", header: "PRIVATE-TOKEN", value: "abc"},
+		{name: "CI/CD job token", token: "glcbt-abc", header: "JOB-TOKEN", value: "glcbt-abc"},
+		{name: "Unprefixed CI/CD job token", token: "abc", opts: []Option{WithJobToken()}, header: "JOB-TOKEN", value: "abc"},
+		{name: "OAuth token", token: "abc", opts: []Option{WithOAuthToken()}, header: "Authorization", value: "Bearer abc"},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			var got http.Header
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"id": 1}`))
+			}))
+			defer server.Close()
+
+			client, err := NewGitLabClient(test.token, append(test.opts, WithBaseURL(server.URL))...)
+			if err != nil {
+				t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if _, err := client.GetProjectID("group/project"); err != nil {
+				t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			for _, header := range []string{"PRIVATE-TOKEN", "JOB-TOKEN", "Authorization"} {
+				want := ""
+				if header == test.header {
+					want = test.value
+				}
+
+				if v := got.Get(header); v != want {
+					t.Errorf("expected %s header %q, got %q", header, want, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Coverage returns the coverage GitLab parses from the pipeline's job logs through their coverage
+// regular expressions.
+func (c *GitLabClient) Coverage(id string, pid int) (*Coverage, error) {
+	pipeline, _, err := c.api.Pipelines.GetPipeline(id, pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pipeline: %w", err)
+	}
+
+	// GitLab averages the coverage of the pipeline's jobs, so it has none if they report none
+	if pipeline.Coverage == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	coverage := &Coverage{}
+	if coverage.Percent, err = strconv.ParseFloat(pipeline.Coverage, 64); err != nil {
+		return nil, fmt.Errorf("malformed pipeline coverage %q: %w", pipeline.Coverage, err)
+	}
+
+	opts := &gitlab.ListJobsOptions{
+		ListOptions:    gitlab.ListOptions{PerPage: maxPageSize},
+		IncludeRetried: gitlab.Ptr(false),
+	}
+
+	for {
+		jobs, resp, err := c.api.Jobs.ListPipelineJobs(id, pid, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve pipeline jobs: %w", err)
+		}
+
+		// Jobs without a coverage regular expression report no coverage, indistinguishable from 0%
+		for _, job := range jobs {
+			if job.Coverage > 0 {
+				coverage.Jobs = append(coverage.Jobs, JobCoverage{Name: job.Name, Percent: job.Coverage})
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return coverage, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+func (c *GitLabClient) DefaultBranch(id string) (string, error) {
+	project, _, err := c.api.Projects.GetProject(projectPath(id), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve project: %w", err)
+	}
+
+	return project.DefaultBranch, nil
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func Test_GitLab_Coverage(ts *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline string
+		expected *Coverage
+	}{
+		{
+			name:     "Pipeline with coverage",
+			pipeline: `{"id": 42, "project_id": 1, "coverage": "81.25"}`,
+			expected: &Coverage{Percent: 81.25, Jobs: []JobCoverage{{Name: "unit", Percent: 87.5}, {Name: "integration", Percent: 75}}},
+		},
+		{
+			name:     "Pipeline without coverage",
+			pipeline: `{"id": 42, "project_id": 1, "coverage": null}`,
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.EscapedPath() {
+				case "/api/v4/projects/1/pipelines/42":
+					w.Write([]byte(test.pipeline))
+				case "/api/v4/projects/1/pipelines/42/jobs":
+					w.Write([]byte(`[{"name": "unit", "coverage": 87.5}, {"name": "lint"}, {"name": "integration", "coverage": 75}]`))
+				default:
+					t.Errorf("unexpected request to %s", r.URL.EscapedPath())
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			mockedAPI, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+			client := GitLabClient{api: mockedAPI}
+
+			got, err := client.Coverage("1", 42)
+			if err != nil {
+				t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
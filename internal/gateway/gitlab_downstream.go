@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// maxDownstreamDepth bounds how deep downstream pipelines are followed, as multi-project pipelines
+// may trigger each other indefinitely.
+const maxDownstreamDepth = 10
+
+// PipelineTree returns a pipeline along with every pipeline triggered by its bridge jobs,
+// recursively. Bridges whose downstream pipeline is yet to be created are skipped.
+func (c *GitLabClient) PipelineTree(id string, pid int) (*PipelineTree, error) {
+	pipeline, err := c.GetPipeline(id, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.pipelineTree(*pipeline, "", maxDownstreamDepth)
+}
+
+func (c *GitLabClient) pipelineTree(pipeline Pipeline, bridge string, depth int) (*PipelineTree, error) {
+	tree := &PipelineTree{Pipeline: pipeline, Bridge: bridge}
+	if depth == 0 {
+		return tree, nil
+	}
+
+	bridges, err := c.pipelineBridges(pipeline.ProjectID, pipeline.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bridges {
+		if b.DownstreamPipeline == nil {
+			continue
+		}
+
+		downstream, err := c.GetPipeline(strconv.Itoa(b.DownstreamPipeline.ProjectID), b.DownstreamPipeline.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := c.pipelineTree(*downstream, b.Name, depth-1)
+		if err != nil {
+			return nil, err
+		}
+
+		tree.Downstream = append(tree.Downstream, *child)
+	}
+
+	return tree, nil
+}
+
+func (c *GitLabClient) pipelineBridges(id string, pid int) ([]*gitlab.Bridge, error) {
+	opts := &gitlab.ListJobsOptions{ListOptions: gitlab.ListOptions{PerPage: maxPageSize}}
+
+	var bridges []*gitlab.Bridge
+
+	for {
+		page, resp, err := c.api.Jobs.ListPipelineBridges(id, pid, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve bridges: %w", err)
+		}
+
+		bridges = append(bridges, page...)
+
+		if resp.NextPage == 0 {
+			return bridges, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
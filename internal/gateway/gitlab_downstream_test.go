@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func Test_GitLab_PipelineTree(t *testing.T) {
+	responses := map[string]string{
+		"/api/v4/projects/1/pipelines/10":         `{"id": 10, "project_id": 1, "status": "success"}`,
+		"/api/v4/projects/1/pipelines/10/bridges": `[{"name": "child", "downstream_pipeline": {"id": 11, "project_id": 1}}]`,
+		// Bridges are paginated
+		"/api/v4/projects/1/pipelines/10/bridges?page=2": `[{"name": "deploy", "downstream_pipeline": {"id": 20, "project_id": 2}}, {"name": "manual"}]`,
+		"/api/v4/projects/1/pipelines/11":                `{"id": 11, "project_id": 1, "status": "running"}`,
+		"/api/v4/projects/1/pipelines/11/bridges":        `[{"name": "grandchild", "downstream_pipeline": {"id": 12, "project_id": 1}}]`,
+		"/api/v4/projects/1/pipelines/12":                `{"id": 12, "project_id": 1, "status": "failed"}`,
+		"/api/v4/projects/1/pipelines/12/bridges":        `[]`,
+		"/api/v4/projects/2/pipelines/20":                `{"id": 20, "project_id": 2, "status": "pending"}`,
+		"/api/v4/projects/2/pipelines/20/bridges":        `[]`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+			path += "?page=" + page
+		}
+
+		if _, ok := responses[path+"?page=2"]; ok {
+			w.Header().Set("X-Next-Page", "2")
+		}
+
+		body, ok := responses[path]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL.EscapedPath())
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.PipelineTree("1", 10)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &PipelineTree{
+		Pipeline: Pipeline{ID: 10, ProjectID: "1", Status: "success"},
+		Downstream: []PipelineTree{
+			{
+				Pipeline: Pipeline{ID: 11, ProjectID: "1", Status: "running"},
+				Bridge:   "child",
+				Downstream: []PipelineTree{
+					{Pipeline: Pipeline{ID: 12, ProjectID: "1", Status: "failed"}, Bridge: "grandchild"},
+				},
+			},
+			{Pipeline: Pipeline{ID: 20, ProjectID: "2", Status: "pending"}, Bridge: "deploy"},
+		},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	pending := got.Pending(client.IsStatusPending)
+	if pending == nil || pending.ID != 11 {
+		t.Errorf("expected pipeline 11 to be pending, got %v", pending)
+	}
+}
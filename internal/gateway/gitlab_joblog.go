@@ -0,0 +1,12 @@
+package gateway
+
+import "fmt"
+
+func (c *GitLabClient) JobLog(id string, jobID int) (string, error) {
+	trace, _, err := c.api.Jobs.GetTraceFile(id, jobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve log of job %d: %w", jobID, err)
+	}
+
+	return readLogTail(trace)
+}
@@ -0,0 +1,39 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func Test_GitLab_JobLog(t *testing.T) {
+	trace := "$ go test ./...\n--- FAIL: TestParse (0.00s)\nERROR: Job failed: exit code 1\n"
+
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(trace)}}))
+
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.JobLog("1", 42)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got != trace {
+		t.Errorf("expected log %q, got %q", trace, got)
+	}
+}
+
+func Test_ReadLogTail(t *testing.T) {
+	log := strings.Repeat("a", maxJobLogSize) + strings.Repeat("b", maxJobLogSize)
+
+	got, err := readLogTail(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got != strings.Repeat("b", maxJobLogSize) {
+		t.Errorf("expected the last %d bytes of the log to be kept", maxJobLogSize)
+	}
+}
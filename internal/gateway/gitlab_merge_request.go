@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func (c *GitLabClient) MergeRequestsByCommit(id, sha string) ([]MergeRequest, error) {
+	mrs, _, err := c.api.Commits.ListMergeRequestsByCommit(projectPath(id), sha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve merge requests: %w", err)
+	}
+
+	converted := make([]MergeRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		converted = append(converted, gitlabMergeRequest(mr))
+	}
+
+	return openFirst(converted), nil
+}
+
+func (c *GitLabClient) OpenMergeRequest(id, branch string) (*MergeRequest, error) {
+	mrs, _, err := c.api.MergeRequests.ListProjectMergeRequests(projectPath(id), &gitlab.ListProjectMergeRequestsOptions{
+		State:        gitlab.Ptr("opened"),
+		SourceBranch: gitlab.Ptr(branch),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve merge requests: %w", err)
+	}
+
+	if len(mrs) == 0 {
+		return nil, fmt.Errorf("%w for branch %s", ErrNoMergeRequest, branch)
+	}
+
+	mr := gitlabMergeRequest(mrs[0])
+
+	return &mr, nil
+}
+
+// MergeRequestPipeline returns the latest of a merge request's pipelines, which may be a merge
+// request pipeline, a merged results pipeline or a branch pipeline.
+func (c *GitLabClient) MergeRequestPipeline(id string, mr *MergeRequest) (*Pipeline, error) {
+	infos, _, err := c.api.MergeRequests.ListMergeRequestPipelines(projectPath(id), mr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve merge request pipelines: %w", err)
+	}
+
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("%w for merge request !%d", ErrNoPipeline, mr.ID)
+	}
+
+	latest := infos[0]
+	for _, info := range infos {
+		if info.ID > latest.ID {
+			latest = info
+		}
+	}
+
+	return c.GetPipeline(strconv.Itoa(latest.ProjectID), latest.ID)
+}
+
+// MergeStatus returns the merge request's detailed merge status. Its external status checks are
+// the checks required to merge it, as GitLab otherwise only requires its pipeline to succeed.
+func (c *GitLabClient) MergeStatus(id string, mr *MergeRequest) (*MergeStatus, error) {
+	detailed, _, err := c.api.MergeRequests.GetMergeRequest(projectPath(id), mr.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve merge request: %w", err)
+	}
+
+	status := &MergeStatus{
+		Mergeable: detailed.DetailedMergeStatus == "mergeable",
+		Detail:    detailed.DetailedMergeStatus,
+	}
+
+	checks, resp, err := c.api.ExternalStatusChecks.ListMergeStatusChecks(projectPath(id), mr.ID, nil)
+	if err != nil {
+		// External status checks are only available on GitLab Ultimate
+		if resp != nil && unavailable(resp.StatusCode) {
+			return status, nil
+		}
+
+		return nil, fmt.Errorf("failed to retrieve merge request status checks: %w", err)
+	}
+
+	for _, check := range checks {
+		status.RequiredChecks = append(status.RequiredChecks, Check{Name: check.Name, Status: check.Status, URL: check.ExternalURL})
+	}
+
+	return status, nil
+}
+
+func gitlabMergeRequest(mr *gitlab.MergeRequest) MergeRequest {
+	return MergeRequest{
+		ID:           mr.IID,
+		Title:        mr.Title,
+		State:        mr.State,
+		SourceBranch: mr.SourceBranch,
+		TargetBranch: mr.TargetBranch,
+		SHA:          mr.SHA,
+		URL:          mr.WebURL,
+	}
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func Test_GitLab_MergeRequestsByCommit(t *testing.T) {
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(`[
+		{"iid": 12, "title": "Draft: refactor", "state": "opened", "source_branch": "refactor", "target_branch": "main", "web_url": "https://gitlab.com/group/project/-/merge_requests/12"}
+	]`)}}))
+
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.MergeRequestsByCommit("group/project", "928e2df")
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := []MergeRequest{
+		{ID: 12, Title: "Draft: refactor", State: "opened", SourceBranch: "refactor", TargetBranch: "main", URL: "https://gitlab.com/group/project/-/merge_requests/12"},
+	}
+
+	if !reflect.DeepEqual(want, got) || !got[0].IsOpen() {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_GitLab_MergeRequestPipeline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fproject/merge_requests/12/pipelines":
+			w.Write([]byte(`[{"id": 40, "project_id": 1}, {"id": 42, "project_id": 1}, {"id": 41, "project_id": 1}]`))
+		case "/api/v4/projects/1/pipelines/42":
+			w.Write([]byte(`{"id": 42, "project_id": 1, "status": "running", "ref": "refs/merge-requests/12/merge", "user": {"username": "root"}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.EscapedPath())
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithBaseURL(server.URL))
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.MergeRequestPipeline("group/project", &MergeRequest{ID: 12})
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &Pipeline{ID: 42, ProjectID: "1", Status: "running", Ref: "refs/merge-requests/12/merge", Author: "root"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
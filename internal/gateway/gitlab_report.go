@@ -0,0 +1,37 @@
+package gateway
+
+import "fmt"
+
+// TestReport returns the pipeline's test report, which GitLab builds from the JUnit reports its
+// jobs upload through artifacts:reports:junit. GitLab names its suites after the jobs, so failures
+// are grouped by their JUnit classname, such as a Go package, where reported.
+func (c *GitLabClient) TestReport(id string, pid int) (*TestReport, error) {
+	report, _, err := c.api.Pipelines.GetPipelineTestReport(id, pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pipeline test report: %w", err)
+	}
+
+	converted := &TestReport{Total: report.TotalCount}
+
+	for _, suite := range report.TestSuites {
+		for _, tc := range suite.TestCases {
+			if tc.Status != "failed" && tc.Status != "error" {
+				continue
+			}
+
+			suiteName := tc.Classname
+			if suiteName == "" {
+				suiteName = suite.Name
+			}
+
+			message := tc.StackTrace
+			if output, ok := tc.SystemOutput.(string); ok && message == "" {
+				message = output
+			}
+
+			converted.Failures = append(converted.Failures, TestFailure{Suite: suiteName, Name: tc.Name, Message: message})
+		}
+	}
+
+	return converted, nil
+}
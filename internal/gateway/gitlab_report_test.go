@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func Test_GitLab_TestReport(t *testing.T) {
+	mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{makeJSONResponse(`{
+		"total_count": 5, "failed_count": 3,
+		"test_suites": [
+			{"name": "rspec", "test_cases": [
+				{"status": "success", "name": "passes", "classname": "spec.foo"},
+				{"status": "failed", "name": "spec.foo fails", "classname": "spec.foo", "stack_trace": "expected 1 got 2"}
+			]},
+			{"name": "go", "test_cases": [
+				{"status": "skipped", "name": "TestSkipped", "classname": "pkg/foo"},
+				{"status": "error", "name": "TestPanics", "classname": "pkg/foo", "system_output": "panic: boom"}
+			]},
+			{"name": "unit-tests", "test_cases": [
+				{"status": "failed", "name": "renders the header", "stack_trace": "expected header"}
+			]}
+		]
+	}`)}}))
+
+	client := GitLabClient{api: mockedAPI}
+
+	got, err := client.TestReport("1", 42)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := &TestReport{
+		Total: 5,
+		Failures: []TestFailure{
+			{Suite: "spec.foo", Name: "spec.foo fails", Message: "expected 1 got 2"},
+			{Suite: "pkg/foo", Name: "TestPanics", Message: "panic: boom"},
+			// Test cases without a classname fall back to the job's suite
+			{Suite: "unit-tests", Name: "renders the header", Message: "expected header"},
+		},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	wantSuites := []SuiteFailures{{Suite: "spec.foo", Failed: 1}, {Suite: "pkg/foo", Failed: 1}, {Suite: "unit-tests", Failed: 1}}
+	if suites := got.FailedSuites(); !reflect.DeepEqual(wantSuites, suites) {
+		t.Errorf("expected %v, got %v", wantSuites, suites)
+	}
+}
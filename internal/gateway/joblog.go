@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// maxJobLogSize is the most of a job's log that is kept, from its end, where failures are reported.
+const maxJobLogSize = 4 << 20
+
+// JobLogReader is implemented by clients of providers that store the logs of pipeline jobs.
+type JobLogReader interface {
+	// JobLog returns the raw log of a job, or an empty log if the job has none, e.g. a commit status.
+	JobLog(id string, jobID int) (string, error)
+}
+
+// readLogTail returns up to the last maxJobLogSize bytes of a log.
+func readLogTail(r io.Reader) (string, error) {
+	var buf bytes.Buffer
+
+	chunk := make([]byte, 32<<10)
+
+	for {
+		n, err := r.Read(chunk)
+		buf.Write(chunk[:n])
+
+		if buf.Len() > 2*maxJobLogSize {
+			buf.Next(buf.Len() - maxJobLogSize)
+		}
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read job log: %w", err)
+		}
+	}
+
+	log := buf.Bytes()
+	if len(log) > maxJobLogSize {
+		log = log[len(log)-maxJobLogSize:]
+	}
+
+	return string(log), nil
+}
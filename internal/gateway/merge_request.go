@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNoMergeRequest is returned when a branch has no open merge request.
+var ErrNoMergeRequest = errors.New("no open merge request found")
+
+// MergeRequest is a GitLab merge request or GitHub pull request.
+type MergeRequest struct {
+	// ID is the merge request's number within its project: GitLab's IID or GitHub's PR number.
+	ID           int
+	Title        string
+	State        string
+	SourceBranch string
+	TargetBranch string
+	// SHA is the latest commit of the source branch.
+	SHA string
+	URL string
+	// Project is set when the merge request was opened in another project than the one it was
+	// looked up from, such as the upstream repository of a fork.
+	Project string
+}
+
+// IsOpen reports whether the merge request is yet to be merged or closed.
+func (mr MergeRequest) IsOpen() bool {
+	return mr.State == "opened" || mr.State == "open"
+}
+
+// MergeStatus describes whether a merge request can be merged.
+type MergeStatus struct {
+	Mergeable bool
+	// Detail is the provider's reason for the merge request's mergeability, e.g. GitLab's
+	// "not_approved" or GitHub's "blocked".
+	Detail string
+	// RequiredChecks are the checks that must pass before the merge request can be merged.
+	RequiredChecks []Check
+}
+
+// Check is a status reported against a commit, such as a GitHub check run or commit status, or a
+// GitLab external status check.
+type Check struct {
+	Name   string
+	Status string
+	URL    string
+}
+
+// MergeRequestFinder is implemented by clients that can find the merge requests containing a commit.
+type MergeRequestFinder interface {
+	// MergeRequestsByCommit returns the merge requests whose changes include a commit, open ones first.
+	MergeRequestsByCommit(id, sha string) ([]MergeRequest, error)
+}
+
+// MergeRequestWatcher is implemented by clients that can watch the pipelines of merge requests,
+// which often differ from the branch pipeline of the same commit: GitLab runs merge request and
+// merged results pipelines, and GitHub runs workflows on pull_request events.
+type MergeRequestWatcher interface {
+	MergeRequestFinder
+
+	// OpenMergeRequest returns the open merge request from a source branch, or ErrNoMergeRequest.
+	OpenMergeRequest(id, branch string) (*MergeRequest, error)
+
+	// MergeRequestPipeline returns the latest pipeline run for a merge request, or ErrNoPipeline.
+	MergeRequestPipeline(id string, mr *MergeRequest) (*Pipeline, error)
+
+	// MergeStatus returns whether a merge request can be merged and the status of its required checks.
+	MergeStatus(id string, mr *MergeRequest) (*MergeStatus, error)
+}
+
+func openFirst(mrs []MergeRequest) []MergeRequest {
+	sort.SliceStable(mrs, func(i, j int) bool {
+		return mrs[i].IsOpen() && !mrs[j].IsOpen()
+	})
+
+	return mrs
+}
@@ -1,17 +1,117 @@
 package gateway
 
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoPipeline is returned when a commit has no pipelines, e.g. because they are yet to be created.
+var ErrNoPipeline = errors.New("no pipelines found")
+
 type Client interface {
 	GetPipelineBySha(id, sha string) (*Pipeline, error)
 	GetPipeline(id string, pid int) (*Pipeline, error)
+	GetPipelineJobs(id string, pid int) ([]Job, error)
+	ListPipelines(id string, opts ListOptions) ([]Pipeline, error)
 	IsStatusPending(status string) bool
+	IsStatusFailed(status string) bool
 }
 
 type Pipeline struct {
-	ID        int
-	ProjectID string
-	CommitSha string
-	Status    string
-	URL       string
+	ID         int
+	ProjectID  string
+	CommitSha  string
+	Ref        string
+	Status     string
+	URL        string
+	Author     string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// QueuedDuration returns how long the pipeline waited between being created and starting to run,
+// or zero if either is unknown.
+func (p *Pipeline) QueuedDuration() time.Duration {
+	if p.CreatedAt.IsZero() || p.StartedAt.IsZero() || p.StartedAt.Before(p.CreatedAt) {
+		return 0
+	}
+
+	return p.StartedAt.Sub(p.CreatedAt)
+}
+
+// Duration returns how long the pipeline has been running for. Pipelines that are
+// yet to start report a zero duration, and unfinished pipelines are measured up to now.
+func (p *Pipeline) Duration() time.Duration {
+	if p.StartedAt.IsZero() {
+		return 0
+	}
+
+	if p.FinishedAt.IsZero() {
+		return time.Since(p.StartedAt)
+	}
+
+	return p.FinishedAt.Sub(p.StartedAt)
+}
+
+// ProjectName returns a human readable "namespace/project" name derived from the
+// pipeline's web URL, falling back to the project ID if it cannot be determined.
+func (p *Pipeline) ProjectName() string {
+	u, err := url.Parse(p.URL)
+	if err != nil || u.Host == "" {
+		return p.ProjectID
+	}
+
+	path := strings.Trim(u.Path, "/")
+	for _, sep := range []string{"/-/", "/actions/", "/pipelines/"} {
+		if before, _, ok := strings.Cut(path, sep); ok {
+			return before
+		}
+	}
+
+	return p.ProjectID
+}
+
+// maxPageSize is the largest page of results returned by either provider.
+const maxPageSize = 100
+
+// ListOptions filters the pipelines returned by Client.ListPipelines, most recent first.
+type ListOptions struct {
+	Ref string
+
+	// Status only lists pipelines with the given status. GitLab's status names are accepted by
+	// both providers.
+	Status string
+
+	// Limit is the most pipelines returned, fetched over as many pages as needed. If zero, a
+	// single page of the provider's default size is returned.
+	Limit int
+
+	// Details fetches every listed pipeline individually where the provider's list omits details,
+	// such as GitLab's authors and timings, at the cost of a request per pipeline.
+	Details bool
+}
+
+func (o ListOptions) perPage() int {
+	return min(o.Limit, maxPageSize)
+}
+
+// more reports whether another page should be fetched after listing n pipelines.
+func (o ListOptions) more(n, nextPage int) bool {
+	return nextPage != 0 && n < o.Limit
+}
+
+type Job struct {
+	ID         int
+	Name       string
+	Stage      string
+	Status     string
+	URL        string
+	StartedAt  time.Time
+	FinishedAt time.Time
 }
 
 type ProviderType string
@@ -32,6 +132,12 @@ const (
 
 	// ghu for GitHub user-to-server tokens.
 	GitHubUserToServer = "ghu_"
+
+	// ghs for GitHub server-to-server tokens, such as GitHub App installation tokens.
+	GitHubServerToServer = "ghs_"
+
+	// github_pat for fine-grained personal access tokens.
+	GitHubFineGrainedPersonalAccessToken = "github_pat_"
 )
 
 // GitLab access token types typically used with the REST API
@@ -67,3 +173,9 @@ const (
 	// glsoat- for GitLab SCIM Token.
 	GitLabSCIMToken = "glsoat-"
 )
+
+// unavailable reports whether a response status means that a feature is missing from a project or
+// can't be read with the client's permissions, rather than that the request failed.
+func unavailable(statusCode int) bool {
+	return statusCode == http.StatusForbidden || statusCode == http.StatusNotFound
+}
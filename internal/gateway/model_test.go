@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Pipeline_ProjectName(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+		want     string
+	}{
+		{name: "GitLab pipeline URL", pipeline: Pipeline{ProjectID: "1", URL: "https://gitlab.com/group/sub/project/-/pipelines/46"}, want: "group/sub/project"},
+		{name: "GitHub workflow run URL", pipeline: Pipeline{ProjectID: "owner/repo", URL: "https://github.com/owner/repo/actions/runs/8858984663"}, want: "owner/repo"},
+		{name: "Legacy GitLab pipeline URL", pipeline: Pipeline{ProjectID: "1", URL: "https://example.com/owner/repo/pipelines/46"}, want: "owner/repo"},
+		{name: "Falls back to project ID", pipeline: Pipeline{ProjectID: "1"}, want: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			if got := tt.pipeline.ProjectName(); got != tt.want {
+				ts.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+}
+
+func Test_Pipeline_Duration(t *testing.T) {
+	started := time.Date(2024, 4, 18, 17, 55, 0, 0, time.UTC)
+
+	if got := (&Pipeline{}).Duration(); got != 0 {
+		t.Errorf("expected zero duration for a pipeline yet to start, got %s", got)
+	}
+
+	if got := (&Pipeline{StartedAt: started, FinishedAt: started.Add(90 * time.Second)}).Duration(); got != 90*time.Second {
+		t.Errorf("expected 1m30s, got %s", got)
+	}
+}
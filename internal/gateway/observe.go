@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestObserver is told about every request a client makes to its provider's API, e.g. to
+// record metrics. A status code of zero means the request failed without a response.
+type RequestObserver interface {
+	ObserveRequest(provider ProviderType, statusCode int, duration time.Duration)
+}
+
+type observedTransport struct {
+	next     http.RoundTripper
+	provider ProviderType
+	observer RequestObserver
+}
+
+// observe wraps a transport to report its requests to an observer, if there is one.
+func observe(next http.RoundTripper, provider ProviderType, observer RequestObserver) http.RoundTripper {
+	if observer == nil {
+		return next
+	}
+
+	return &observedTransport{next: next, provider: provider, observer: observer}
+}
+
+func (t *observedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := t.next.RoundTrip(req)
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+
+	t.observer.ObserveRequest(t.provider, statusCode, time.Since(start))
+
+	return resp, err
+}
@@ -0,0 +1,72 @@
+package gateway
+
+type options struct {
+	baseURL   string
+	githubApp *GitHubApp
+	observer  RequestObserver
+	// githubChecks is set by WithGitHubChecks.
+	githubChecks bool
+	// oauthToken is set by WithOAuthToken.
+	oauthToken bool
+	// jobToken is set by WithJobToken.
+	jobToken bool
+}
+
+type Option func(*options)
+
+// WithBaseURL points a client at a self-hosted instance's API, e.g. https://gitlab.example.com/api/v4
+// or https://github.example.com/api/v3.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithGitHubApp authenticates a GitHub client as an app installation rather than with a token.
+func WithGitHubApp(app GitHubApp) Option {
+	return func(o *options) {
+		o.githubApp = &app
+	}
+}
+
+// WithRequestObserver reports every request made to the provider's API to an observer.
+func WithRequestObserver(observer RequestObserver) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+// WithGitHubChecks makes a GitHub client aggregate each commit's check runs and commit statuses
+// into its pipeline, with every check as a job, for repositories whose CI is reported by
+// third-party apps rather than GitHub Actions. It has no effect on other providers.
+func WithGitHubChecks() Option {
+	return func(o *options) {
+		o.githubChecks = true
+	}
+}
+
+// WithOAuthToken marks the client's token as an OAuth access token, which GitLab only accepts as a
+// bearer token. GitHub accepts every kind of token the same way.
+func WithOAuthToken() Option {
+	return func(o *options) {
+		o.oauthToken = true
+	}
+}
+
+// WithJobToken marks the client's token as a GitLab CI/CD job token, which GitLab only accepts in
+// the JOB-TOKEN header. Tokens with the glcbt- prefix are recognised as job tokens without it, but
+// instances may issue job tokens without a prefix. It has no effect on other providers.
+func WithJobToken() Option {
+	return func(o *options) {
+		o.jobToken = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
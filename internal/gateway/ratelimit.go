@@ -0,0 +1,265 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRetryWait is the longest a rate limited request waits before being retried. Longer
+	// waits are left to the caller, which sees the provider's rate limit error instead of
+	// stalling silently.
+	maxRetryWait = 10 * time.Second
+
+	// maxRetries is the number of times a rate limited request is retried.
+	maxRetries = 3
+
+	// defaultRetryWait is used when a rate limited response doesn't say when to retry.
+	defaultRetryWait = 5 * time.Second
+
+	// maxCacheSize bounds the total size of the response bodies kept for conditional requests.
+	maxCacheSize = 8 << 20
+
+	// maxCachedResponseSize is the largest response body cached, so that large downloads such
+	// as logs and artifacts are streamed rather than held in memory.
+	maxCachedResponseSize = 1 << 20
+
+	// lowQuotaFraction is the fraction of the rate limit below which polling is stretched.
+	lowQuotaFraction = 4
+)
+
+// RateLimit is a provider's API quota as last reported by its response headers.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// RateLimiter is implemented by clients that track their provider's API quota.
+type RateLimiter interface {
+	RateLimit() (RateLimit, bool)
+}
+
+// Interval stretches a poll frequency once less than a quarter of the quota remains, spreading the
+// remaining requests evenly until the quota resets. An exhausted quota waits for the reset.
+func (r RateLimit) Interval(freq time.Duration, now time.Time) time.Duration {
+	untilReset := r.Reset.Sub(now)
+	if r.Limit == 0 || untilReset <= 0 || r.Remaining > r.Limit/lowQuotaFraction {
+		return freq
+	}
+
+	if r.Remaining == 0 {
+		return untilReset
+	}
+
+	return max(freq, untilReset/time.Duration(r.Remaining))
+}
+
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// rateLimitTransport records the API quota reported by GitHub (X-RateLimit-*) and GitLab
+// (RateLimit-*), waits out Retry-After responses, and revalidates GET requests with their
+// previous ETag so unchanged responses, which GitHub doesn't count against the quota, are
+// served from memory.
+type rateLimitTransport struct {
+	next  http.RoundTripper
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu    sync.Mutex
+	limit RateLimit
+	known bool
+	cache map[string]cachedResponse
+	// cacheSize is the total size of the cached response bodies.
+	cacheSize int
+}
+
+func newRateLimitTransport(next http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{
+		next:  next,
+		now:   time.Now,
+		sleep: sleepContext,
+		cache: make(map[string]cachedResponse),
+	}
+}
+
+func (t *rateLimitTransport) RateLimit() (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.limit, t.known
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+
+	t.mu.Lock()
+	cached, ok := t.cache[key]
+	t.mu.Unlock()
+
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		t.record(resp.Header)
+
+		wait, limited := t.retryAfter(resp)
+		if !limited {
+			return t.handle(key, resp, cached, ok)
+		}
+
+		if wait > maxRetryWait || attempt == maxRetries {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// handle serves unchanged responses from the cache and caches new responses carrying an ETag.
+func (t *rateLimitTransport) handle(key string, resp *http.Response, cached cachedResponse, ok bool) (*http.Response, error) {
+	if resp.StatusCode == http.StatusNotModified && ok {
+		resp.Body.Close()
+
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+
+		resp.StatusCode = http.StatusOK
+		resp.Status = http.StatusText(http.StatusOK)
+		resp.Header = header
+		resp.Body = io.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	// Only the start of the body is read until it is known to be small enough to cache
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedResponseSize+1))
+	if err != nil {
+		resp.Body.Close()
+
+		return nil, err
+	}
+
+	if len(body) > maxCachedResponseSize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(key, cachedResponse{etag: etag, header: resp.Header.Clone(), body: body})
+
+	return resp, nil
+}
+
+// store caches a response, evicting others until the cache's bodies fit within maxCacheSize.
+func (t *rateLimitTransport) store(key string, cached cachedResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if previous, ok := t.cache[key]; ok {
+		t.cacheSize -= len(previous.body)
+		delete(t.cache, key)
+	}
+
+	for k, evicted := range t.cache {
+		if t.cacheSize+len(cached.body) <= maxCacheSize {
+			break
+		}
+
+		t.cacheSize -= len(evicted.body)
+		delete(t.cache, k)
+	}
+
+	t.cache[key] = cached
+	t.cacheSize += len(cached.body)
+}
+
+// record stores the quota reported by a response, if any.
+func (t *rateLimitTransport) record(h http.Header) {
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		remaining, err := strconv.Atoi(h.Get(prefix + "Remaining"))
+		if err != nil {
+			continue
+		}
+
+		limit, _ := strconv.Atoi(h.Get(prefix + "Limit"))
+		reset, _ := strconv.ParseInt(h.Get(prefix+"Reset"), 10, 64)
+
+		t.mu.Lock()
+		t.limit = RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}
+		t.known = true
+		t.mu.Unlock()
+
+		return
+	}
+}
+
+// retryAfter reports whether a response was rate limited and how long to wait before retrying,
+// preferring the Retry-After header over the quota's reset time.
+func (t *rateLimitTransport) retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	limit, known := t.RateLimit()
+	if !known || limit.Remaining > 0 {
+		// A 403 without an exhausted quota is a permissions problem, not a rate limit
+		return defaultRetryWait, resp.StatusCode == http.StatusTooManyRequests
+	}
+
+	return max(limit.Reset.Sub(t.now()), 0), true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_RateLimit_Interval(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	freq := 5 * time.Second
+
+	tests := []struct {
+		name  string
+		limit RateLimit
+
+		want time.Duration
+	}{
+		{name: "Unknown quota", limit: RateLimit{}, want: freq},
+		{name: "Plenty of quota", limit: RateLimit{Limit: 5000, Remaining: 4000, Reset: now.Add(time.Hour)}, want: freq},
+		{name: "Low quota is spread until reset", limit: RateLimit{Limit: 5000, Remaining: 100, Reset: now.Add(time.Hour)}, want: 36 * time.Second},
+		{name: "Low quota never polls faster than the frequency", limit: RateLimit{Limit: 5000, Remaining: 1000, Reset: now.Add(time.Minute)}, want: freq},
+		{name: "Exhausted quota waits for reset", limit: RateLimit{Limit: 5000, Remaining: 0, Reset: now.Add(10 * time.Minute)}, want: 10 * time.Minute},
+		{name: "Quota already reset", limit: RateLimit{Limit: 5000, Remaining: 0, Reset: now.Add(-time.Minute)}, want: freq},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			if got := tt.limit.Interval(freq, now); got != tt.want {
+				ts.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_rateLimitTransport(t *testing.T) {
+	requests := 0
+	limited := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(5000-requests))
+		w.Header().Set("X-RateLimit-Reset", "1714564800")
+
+		switch {
+		case limited:
+			limited = false
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`{"status":"running"}`))
+		}
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+
+	transport := newRateLimitTransport(http.DefaultTransport)
+	transport.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || string(body) != `{"status":"running"}` {
+			t.Errorf("request %d: expected cached running status, got %d %s", i, resp.StatusCode, body)
+		}
+	}
+
+	if len(slept) != 1 || slept[0] != 3*time.Second {
+		t.Errorf("expected a single 3s wait for Retry-After, got %v", slept)
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 requests to the server, got %d", requests)
+	}
+
+	limit, ok := transport.RateLimit()
+	if !ok || limit.Limit != 5000 || limit.Remaining != 4997 || !limit.Reset.Equal(time.Unix(1714564800, 0)) {
+		t.Errorf("expected recorded rate limit, got %v", limit)
+	}
+}
+
+func Test_rateLimitTransport_LongRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport)
+	transport.sleep = func(_ context.Context, d time.Duration) error {
+		t.Errorf("expected no wait, got %s", d)
+		return nil
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+	resp.Body.Close()
+
+	// The rate limited response is left for the client to report
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+}
+
+func Test_rateLimitTransport_CacheSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+		w.Header().Set("ETag", `"`+r.URL.RawQuery+`"`)
+		w.Write(bytes.Repeat([]byte("a"), size))
+	}))
+	defer server.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport)
+	client := &http.Client{Transport: transport}
+
+	get := func(size int) {
+		resp, err := client.Get(server.URL + "?size=" + strconv.Itoa(size))
+		if err != nil {
+			t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+		}
+		defer resp.Body.Close()
+
+		if body, _ := io.ReadAll(resp.Body); len(body) != size {
+			t.Errorf("expected a %d byte body, got %d bytes", size, len(body))
+		}
+	}
+
+	// Responses too large to cache are passed through in full
+	get(maxCachedResponseSize + 1)
+
+	for size := 1; size <= 12; size++ {
+		get(size << 20)
+	}
+
+	if len(transport.cache) == 0 || transport.cacheSize > maxCacheSize {
+		t.Errorf("expected cached bodies to fit within %d bytes, got %d in %d responses", maxCacheSize, transport.cacheSize, len(transport.cache))
+	}
+
+	var total int
+	for _, cached := range transport.cache {
+		total += len(cached.body)
+	}
+
+	if total != transport.cacheSize {
+		t.Errorf("expected tracked cache size %d to match cached bodies, got %d", transport.cacheSize, total)
+	}
+}
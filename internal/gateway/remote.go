@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ParseRemoteURL splits a git remote into its host and repository path. Both URL
+// (https://host/owner/repo.git) and scp-like (git@host:owner/repo.git) remotes are
+// supported, while anything else is treated as a bare repository path with no host.
+func ParseRemoteURL(remoteURL string) (string, string) {
+	remoteURL = strings.TrimSpace(remoteURL)
+
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err == nil {
+			return u.Hostname(), trimRepoPath(u.Path)
+		}
+	}
+
+	// scp-like syntax requires a colon before the first slash
+	if host, p, ok := strings.Cut(remoteURL, ":"); ok && !strings.Contains(host, "/") {
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+
+		return host, trimRepoPath(p)
+	}
+
+	return "", trimRepoPath(remoteURL)
+}
+
+func trimRepoPath(p string) string {
+	return strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+}
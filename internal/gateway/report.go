@@ -0,0 +1,47 @@
+package gateway
+
+// TestReport summarises the test results reported by a pipeline's jobs.
+type TestReport struct {
+	// Total is the number of tests run, or zero where the provider only reports failures.
+	Total    int
+	Failures []TestFailure
+}
+
+// TestFailure is a failed or errored test.
+type TestFailure struct {
+	// Suite groups related tests, e.g. a package, class or test file.
+	Suite   string
+	Name    string
+	Message string
+}
+
+// TestReporter is implemented by clients of providers that collect test results from pipelines.
+type TestReporter interface {
+	TestReport(id string, pid int) (*TestReport, error)
+}
+
+// SuiteFailures is the number of failed tests of a suite.
+type SuiteFailures struct {
+	Suite  string
+	Failed int
+}
+
+// FailedSuites returns the number of failed tests of each suite, in the order they first failed.
+func (r *TestReport) FailedSuites() []SuiteFailures {
+	var suites []SuiteFailures
+
+	index := make(map[string]int)
+
+	for _, failure := range r.Failures {
+		i, ok := index[failure.Suite]
+		if !ok {
+			i = len(suites)
+			index[failure.Suite] = i
+			suites = append(suites, SuiteFailures{Suite: failure.Suite})
+		}
+
+		suites[i].Failed++
+	}
+
+	return suites
+}
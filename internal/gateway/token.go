@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when a provider rejects an access token outright.
+var ErrInvalidToken = errors.New("access token was rejected")
+
+// expiryWarning is how far ahead of a token's expiry the preflight starts warning about it.
+const expiryWarning = 7 * 24 * time.Hour
+
+// tokenHint tells users how they can replace a token that cannot be used.
+const tokenHint = "pass a new token with -access-token, set ACCESS_TOKEN or run 'pipescope auth login'"
+
+// TokenInfo describes the identity and permissions behind an access token.
+type TokenInfo struct {
+	Provider ProviderType
+	User     string
+	// Scopes is nil when the provider does not report them, as with fine-grained GitHub
+	// tokens, where permissions are instead checked by each request.
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// TokenValidator is implemented by clients that can verify their credentials ahead of use.
+type TokenValidator interface {
+	// ValidateToken returns nil if the client has no token, as when reading public projects.
+	ValidateToken() (*TokenInfo, error)
+}
+
+// scopeRequirement lists the scopes of which at least one is needed to read pipelines and jobs.
+type scopeRequirement struct {
+	scopes []string
+	// optional requirements only restrict access to some projects, so are warned about instead.
+	optional bool
+	reason   string
+}
+
+var requiredScopes = map[ProviderType]scopeRequirement{
+	GitHub: {scopes: []string{"repo"}, optional: true, reason: "private repositories cannot be read"},
+	GitLab: {scopes: []string{"read_api", "api"}, reason: "pipelines cannot be read"},
+}
+
+// Preflight validates a client's access token before it is used to watch pipelines. An actionable
+// error is returned if the token cannot be used at all, and warnings are returned for problems that
+// may cause later requests to fail. Clients that cannot validate their tokens, or have none, are
+// always accepted.
+func Preflight(c Client) ([]string, error) {
+	validator, ok := c.(TokenValidator)
+	if !ok {
+		return nil, nil
+	}
+
+	info, err := validator.ValidateToken()
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return nil, fmt.Errorf("%w: it may be invalid, expired or revoked; %s", err, tokenHint)
+		}
+
+		return nil, err
+	} else if info == nil {
+		return nil, nil
+	}
+
+	return checkToken(info, time.Now())
+}
+
+func checkToken(info *TokenInfo, now time.Time) ([]string, error) {
+	var warnings []string
+
+	if !info.ExpiresAt.IsZero() {
+		switch remaining := info.ExpiresAt.Sub(now); {
+		case remaining <= 0:
+			return nil, fmt.Errorf("%w: token expired on %s; %s", ErrInvalidToken, info.ExpiresAt.Format(time.DateOnly), tokenHint)
+		case remaining < expiryWarning:
+			warnings = append(warnings, fmt.Sprintf("access token expires on %s", info.ExpiresAt.Format(time.DateOnly)))
+		}
+	}
+
+	req, ok := requiredScopes[info.Provider]
+	if !ok || info.Scopes == nil || slices.ContainsFunc(req.scopes, func(s string) bool { return slices.Contains(info.Scopes, s) }) {
+		return warnings, nil
+	}
+
+	msg := fmt.Sprintf("access token is missing the %s scope (has %s): %s", strings.Join(req.scopes, " or "), formatScopes(info.Scopes), req.reason)
+	if !req.optional {
+		return nil, fmt.Errorf("%s; %s", msg, tokenHint)
+	}
+
+	return append(warnings, msg), nil
+}
+
+func formatScopes(scopes []string) string {
+	if len(scopes) == 0 {
+		return "no scopes"
+	}
+
+	return strings.Join(scopes, ", ")
+}
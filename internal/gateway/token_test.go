@@ -0,0 +1,246 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+func makeResponse(status int, header http.Header, body string) *http.Response {
+	recorder := httptest.NewRecorder()
+	for k, v := range header {
+		recorder.Header()[k] = v
+	}
+	recorder.Header().Set("Content-Type", "application/json")
+	recorder.WriteHeader(status)
+	recorder.WriteString(body)
+	return recorder.Result()
+}
+
+func Test_checkToken(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		info TokenInfo
+
+		wantWarnings int
+		wantErr      bool
+	}{
+		{name: "Unknown scopes are accepted", info: TokenInfo{Provider: GitHub}},
+		{name: "GitHub token with repo scope", info: TokenInfo{Provider: GitHub, Scopes: []string{"repo", "workflow"}}},
+		{name: "GitHub token without repo scope warns", info: TokenInfo{Provider: GitHub, Scopes: []string{}}, wantWarnings: 1},
+		{name: "GitLab token with api scope", info: TokenInfo{Provider: GitLab, Scopes: []string{"api"}}},
+		{name: "GitLab token without read_api scope fails", info: TokenInfo{Provider: GitLab, Scopes: []string{"read_user"}}, wantErr: true},
+		{name: "Token expiring soon warns", info: TokenInfo{Provider: GitLab, ExpiresAt: now.Add(48 * time.Hour)}, wantWarnings: 1},
+		{name: "Expired token fails", info: TokenInfo{Provider: GitHub, ExpiresAt: now.Add(-time.Hour)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			warnings, err := checkToken(&tt.info, now)
+			if tt.wantErr {
+				if err == nil {
+					ts.Error("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				ts.Errorf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if len(warnings) != tt.wantWarnings {
+				ts.Errorf("expected %d warnings, got %v", tt.wantWarnings, warnings)
+			}
+		})
+	}
+}
+
+func Test_GitHub_ValidateToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *http.Response
+
+		want        *TokenInfo
+		wantInvalid bool
+		wantErr     bool
+	}{
+		{
+			name: "Classic token reports scopes and expiry",
+			response: makeResponse(http.StatusOK, http.Header{
+				"X-Oauth-Scopes":                         {"repo, read:org"},
+				"Github-Authentication-Token-Expiration": {"2024-06-01 12:00:00 UTC"},
+			}, `{"login":"octocat"}`),
+			want: &TokenInfo{
+				Provider:  GitHub,
+				User:      "octocat",
+				Scopes:    []string{"repo", "read:org"},
+				ExpiresAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "Fine-grained token reports no scopes",
+			response: makeResponse(http.StatusOK, nil, `{"login":"octocat"}`),
+			want:     &TokenInfo{Provider: GitHub, User: "octocat"},
+		},
+		{
+			name:        "Rejected token",
+			response:    makeResponse(http.StatusUnauthorized, nil, `{"message":"Bad credentials"}`),
+			wantErr:     true,
+			wantInvalid: true,
+		},
+		{
+			name:     "Other failures are not reported as invalid tokens",
+			response: makeResponse(http.StatusInternalServerError, nil, `{}`),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			client := GitHubClient{
+				api: github.NewClient(&http.Client{Transport: &mockRoundTripper{tt.response}}),
+			}
+
+			got, err := client.ValidateToken()
+			if tt.wantErr {
+				if err == nil {
+					ts.Fatal("expected an error, got nil")
+				}
+
+				if errors.Is(err, ErrInvalidToken) != tt.wantInvalid {
+					ts.Errorf("expected invalid token error to be %t, got %s", tt.wantInvalid, err)
+				}
+				return
+			}
+
+			if err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_GitLab_ValidateToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *http.Response
+
+		want        *TokenInfo
+		wantInvalid bool
+		wantErr     bool
+	}{
+		{
+			name: "Personal access token reports scopes and expiry",
+			response: makeResponse(http.StatusOK, nil, `{
+				"id": 1,
+				"name": "pipescope",
+				"active": true,
+				"revoked": false,
+				"scopes": ["read_api"],
+				"expires_at": "2024-06-01"
+			}`),
+			want: &TokenInfo{
+				Provider:  GitLab,
+				Scopes:    []string{"read_api"},
+				ExpiresAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:        "Revoked token",
+			response:    makeResponse(http.StatusOK, nil, `{"name":"pipescope","active":false,"revoked":true}`),
+			wantErr:     true,
+			wantInvalid: true,
+		},
+		{
+			name:        "Rejected token",
+			response:    makeResponse(http.StatusUnauthorized, nil, `{"message":"401 Unauthorized"}`),
+			wantErr:     true,
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			mockedAPI, _ := gitlab.NewClient("", gitlab.WithHTTPClient(&http.Client{Transport: &mockRoundTripper{tt.response}}))
+
+			client := GitLabClient{
+				api: mockedAPI,
+			}
+
+			got, err := client.ValidateToken()
+			if tt.wantErr {
+				if err == nil {
+					ts.Fatal("expected an error, got nil")
+				}
+
+				if errors.Is(err, ErrInvalidToken) != tt.wantInvalid {
+					ts.Errorf("expected invalid token error to be %t, got %s", tt.wantInvalid, err)
+				}
+				return
+			}
+
+			if err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_Preflight_Anonymous(t *testing.T) {
+	// Anonymous clients can read public projects, but would be rejected by the user endpoints
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	githubClient, err := NewGitHubClient("", WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	gitlabClient, err := NewGitLabClient("", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	for _, client := range []Client{githubClient, gitlabClient} {
+		if warnings, err := Preflight(client); err != nil || len(warnings) > 0 {
+			t.Errorf("expected %T to pass preflight, got %v and error %v", client, warnings, err)
+		}
+	}
+}
+
+func Test_Preflight_JobToken(t *testing.T) {
+	// Job tokens cannot read the token or user endpoints, so aren't checked against them
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewGitLabClient("64_abc", WithBaseURL(server.URL), WithJobToken())
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if warnings, err := Preflight(client); err != nil || len(warnings) > 0 {
+		t.Errorf("expected preflight to pass, got %v and error %v", warnings, err)
+	}
+}
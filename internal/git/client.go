@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/go-git/go-git/v5"
@@ -8,6 +9,7 @@ import (
 
 type Client interface {
 	GetHead() (string, error)
+	GetBranch() (string, error)
 	GetRemoteURL() (string, error)
 }
 
@@ -35,6 +37,20 @@ func (c *ClientImpl) GetHead() (string, error) {
 	return head.Hash().String(), nil
 }
 
+// GetBranch returns the name of the checked out branch.
+func (c *ClientImpl) GetBranch() (string, error) {
+	head, err := c.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD of repository: %w", err)
+	}
+
+	if !head.Name().IsBranch() {
+		return "", errors.New("HEAD is detached: check out a branch")
+	}
+
+	return head.Name().Short(), nil
+}
+
 func (c *ClientImpl) GetRemoteURL() (string, error) {
 	remote, err := c.repo.Remote("origin")
 	if err != nil {
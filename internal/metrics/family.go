@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type kind string
+
+const (
+	counter   kind = "counter"
+	gauge     kind = "gauge"
+	histogram kind = "histogram"
+)
+
+// family is a metric and every labelled series recorded for it.
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// counts holds the number of observations in each histogram bucket, non-cumulatively.
+	counts []uint64
+	count  uint64
+}
+
+func newFamily(k kind, name, help string, labels ...string) *family {
+	return &family{name: name, help: help, kind: k, labels: labels, series: make(map[string]*series)}
+}
+
+func newHistogram(name, help string, buckets []float64, labels ...string) *family {
+	f := newFamily(histogram, name, help, labels...)
+	f.buckets = buckets
+
+	return f
+}
+
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\xff")
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// add increments a counter for the given label values.
+func (f *family) add(v float64, labelValues ...string) {
+	f.get(labelValues).value += v
+}
+
+// set sets a gauge for the given label values.
+func (f *family) set(v float64, labelValues ...string) {
+	f.get(labelValues).value = v
+}
+
+// observe records a histogram observation for the given label values.
+func (f *family) observe(v float64, labelValues ...string) {
+	s := f.get(labelValues)
+	s.value += v
+	s.count++
+
+	for i, bound := range f.buckets {
+		if v <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+}
+
+// write renders the family in the Prometheus text exposition format, with series sorted by their
+// label values so that output is stable.
+func (f *family) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.series[k]
+
+		if f.kind != histogram {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelSet(s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labelSet(s.labelValues, "le", formatFloat(bound)), cumulative)
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labelSet(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.labelSet(s.labelValues, "", ""), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.labelSet(s.labelValues, "", ""), s.count)
+	}
+}
+
+// labelSet renders a series' labels, with an extra label appended if its name is non-empty.
+func (f *family) labelSet(values []string, extraName, extraValue string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, f.labels[i]+`="`+labelEscaper.Replace(v)+`"`)
+	}
+
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper escapes label values as required by the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
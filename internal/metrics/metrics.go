@@ -0,0 +1,135 @@
+// Package metrics records Prometheus metrics from the pipelines pipescope watches and the API
+// requests made to watch them, exposing them in the text exposition format.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+var (
+	pipelineBuckets = []float64{30, 60, 120, 300, 600, 900, 1800, 3600, 7200}
+	queueBuckets    = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+	requestBuckets  = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+// Registry holds every metric recorded by pipescope. It is a notifier.Notifier, recording
+// pipelines as they complete, and a gateway.RequestObserver, recording API requests.
+type Registry struct {
+	mu sync.Mutex
+
+	pipelines        *family
+	pipelineDuration *family
+	queueDuration    *family
+	jobFailures      *family
+	requests         *family
+	requestDuration  *family
+	rateLimits       map[string][]gateway.RateLimiter
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		pipelines: newFamily(counter, "pipescope_pipelines_total",
+			"Completed pipelines by project, ref and final status.", "project", "ref", "status"),
+		pipelineDuration: newHistogram("pipescope_pipeline_duration_seconds",
+			"Time completed pipelines spent running.", pipelineBuckets, "project", "ref", "status"),
+		queueDuration: newHistogram("pipescope_pipeline_queue_seconds",
+			"Time completed pipelines spent queued between being created and running.", queueBuckets, "project", "ref"),
+		jobFailures: newFamily(counter, "pipescope_job_failures_total",
+			"Failed jobs of completed pipelines by project, ref and job.", "project", "ref", "job"),
+		requests: newFamily(counter, "pipescope_api_requests_total",
+			"Requests made to provider APIs by response status code, which is 0 for failed requests.", "provider", "code"),
+		requestDuration: newHistogram("pipescope_api_request_duration_seconds",
+			"Latency of requests made to provider APIs.", requestBuckets, "provider"),
+		rateLimits: make(map[string][]gateway.RateLimiter),
+	}
+}
+
+// Notify records a pipeline once it completes.
+func (r *Registry) Notify(event checker.Event) error {
+	if event.Type != checker.EventCompleted {
+		return nil
+	}
+
+	p := event.Pipeline
+	project := p.ProjectName()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pipelines.add(1, project, p.Ref, p.Status)
+
+	if d := p.Duration(); d > 0 {
+		r.pipelineDuration.observe(d.Seconds(), project, p.Ref, p.Status)
+	}
+
+	if d := p.QueuedDuration(); d > 0 {
+		r.queueDuration.observe(d.Seconds(), project, p.Ref)
+	}
+
+	for _, job := range event.FailedJobs {
+		r.jobFailures.add(1, project, p.Ref, job.Name)
+	}
+
+	return nil
+}
+
+// ObserveRequest records a request made to a provider's API.
+func (r *Registry) ObserveRequest(provider gateway.ProviderType, statusCode int, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests.add(1, string(provider), strconv.Itoa(statusCode))
+	r.requestDuration.observe(duration.Seconds(), string(provider))
+}
+
+// AddRateLimiter reports the API quota of a client authenticated with a host. When several
+// clients share a host, the lowest remaining quota is reported.
+func (r *Registry) AddRateLimiter(host string, limiter gateway.RateLimiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rateLimits[host] = append(r.rateLimits[host], limiter)
+}
+
+// ServeHTTP writes every metric in the Prometheus text exposition format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	remaining := newFamily(gauge, "pipescope_rate_limit_remaining", "API requests remaining in the current rate limit window.", "host")
+	limit := newFamily(gauge, "pipescope_rate_limit_limit", "API requests allowed in each rate limit window.", "host")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for host, limiters := range r.rateLimits {
+		var (
+			lowest gateway.RateLimit
+			found  bool
+		)
+
+		for _, limiter := range limiters {
+			if l, ok := limiter.RateLimit(); ok && (!found || l.Remaining < lowest.Remaining) {
+				lowest, found = l, true
+			}
+		}
+
+		if found {
+			remaining.set(float64(lowest.Remaining), host)
+			limit.set(float64(lowest.Limit), host)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	for _, f := range []*family{
+		r.pipelines, r.pipelineDuration, r.queueDuration, r.jobFailures,
+		r.requests, r.requestDuration, remaining, limit,
+	} {
+		f.write(w)
+	}
+}
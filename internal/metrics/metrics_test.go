@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+type fixedLimiter struct {
+	limit gateway.RateLimit
+	known bool
+}
+
+func (l fixedLimiter) RateLimit() (gateway.RateLimit, bool) { return l.limit, l.known }
+
+func Test_Registry(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	r := New()
+
+	events := []checker.Event{
+		{Type: checker.EventStatusChanged, Pipeline: &gateway.Pipeline{ProjectID: "group/project", Ref: "main", Status: "running"}},
+		{
+			Type: checker.EventCompleted,
+			Pipeline: &gateway.Pipeline{
+				ProjectID:  "group/project",
+				Ref:        "main",
+				Status:     "failed",
+				CreatedAt:  created,
+				StartedAt:  created.Add(20 * time.Second),
+				FinishedAt: created.Add(20*time.Second + 90*time.Second),
+			},
+			Failed:     true,
+			FailedJobs: []gateway.Job{{Name: `lint "strict"`}},
+		},
+	}
+
+	for _, event := range events {
+		if err := r.Notify(event); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	r.ObserveRequest(gateway.GitLab, http.StatusOK, 300*time.Millisecond)
+	r.ObserveRequest(gateway.GitLab, 0, time.Second)
+	r.AddRateLimiter("gitlab.com", fixedLimiter{limit: gateway.RateLimit{Limit: 2000, Remaining: 1500}, known: true})
+	r.AddRateLimiter("gitlab.com", fixedLimiter{limit: gateway.RateLimit{Limit: 2000, Remaining: 700}, known: true})
+	r.AddRateLimiter("github.com", fixedLimiter{})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "Completed pipelines are counted", line: `pipescope_pipelines_total{project="group/project",ref="main",status="failed"} 1`},
+		{name: "Durations fall in their bucket", line: `pipescope_pipeline_duration_seconds_bucket{project="group/project",ref="main",status="failed",le="60"} 0`},
+		{name: "Histogram buckets are cumulative", line: `pipescope_pipeline_duration_seconds_bucket{project="group/project",ref="main",status="failed",le="300"} 1`},
+		{name: "Duration sum", line: `pipescope_pipeline_duration_seconds_sum{project="group/project",ref="main",status="failed"} 90`},
+		{name: "Queue time", line: `pipescope_pipeline_queue_seconds_sum{project="group/project",ref="main"} 20`},
+		{name: "Job names are escaped", line: `pipescope_job_failures_total{project="group/project",ref="main",job="lint \"strict\""} 1`},
+		{name: "Requests by status code", line: `pipescope_api_requests_total{provider="gitlab",code="0"} 1`},
+		{name: "Request latency", line: `pipescope_api_request_duration_seconds_count{provider="gitlab"} 2`},
+		{name: "Lowest remaining quota per host", line: `pipescope_rate_limit_remaining{host="gitlab.com"} 700`},
+		{name: "Rate limit", line: `pipescope_rate_limit_limit{host="gitlab.com"} 2000`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			if !strings.Contains(body, tt.line+"\n") {
+				ts.Errorf("expected %q in:\n%s", tt.line, body)
+			}
+		})
+	}
+
+	if strings.Contains(body, `status="running"`) {
+		t.Errorf("expected only completed pipelines to be recorded, got:\n%s", body)
+	}
+
+	if strings.Contains(body, `host="github.com"`) {
+		t.Errorf("expected unknown rate limits to be omitted, got:\n%s", body)
+	}
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+// Command runs a shell command for each event, describing the pipeline through
+// PIPESCOPE_* environment variables. The command's output is passed through to pipescope's.
+type Command struct {
+	command string
+}
+
+func NewCommand(command string) *Command {
+	return &Command{command: command}
+}
+
+func (c *Command) Notify(event checker.Event) error {
+	cmd := shellCommand(c.command)
+	cmd.Env = append(os.Environ(), commandEnv(event)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %q failed: %w", c.command, err)
+	}
+
+	return nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+
+	return exec.Command("sh", "-c", command)
+}
+
+func commandEnv(event checker.Event) []string {
+	m := newMessage(event)
+
+	return []string{
+		"PIPESCOPE_EVENT=" + m.Event,
+		"PIPESCOPE_PROJECT=" + m.Project,
+		"PIPESCOPE_PROJECT_ID=" + event.Pipeline.ProjectID,
+		"PIPESCOPE_REF=" + m.Ref,
+		"PIPESCOPE_SHA=" + m.SHA,
+		"PIPESCOPE_PIPELINE_ID=" + strconv.Itoa(m.PipelineID),
+		"PIPESCOPE_STATUS=" + m.Status,
+		"PIPESCOPE_PREVIOUS_STATUS=" + m.PreviousStatus,
+		"PIPESCOPE_FAILED=" + strconv.FormatBool(m.Failed),
+		"PIPESCOPE_DURATION_SECONDS=" + strconv.Itoa(m.DurationSeconds),
+		"PIPESCOPE_FAILED_JOBS=" + strings.Join(m.FailedJobs, ","),
+		"PIPESCOPE_URL=" + m.URL,
+	}
+}
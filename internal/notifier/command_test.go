@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_Command_Notify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are run through sh")
+	}
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+
+	c := NewCommand(`echo "$PIPESCOPE_STATUS $PIPESCOPE_PIPELINE_ID $PIPESCOPE_SHA $PIPESCOPE_FAILED_JOBS $PIPESCOPE_URL" > ` + out)
+	if err := c.Notify(completedEvent()); err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("expected hook to write output: %s", err)
+	}
+
+	want := "failed 1253625945 928e2dfffdaaf6fa32f3ee4bf608690b09c6e2c1 test https://gitlab.com/gregfurman/sample-project/-/pipelines/1253625945"
+	if strings.TrimSpace(string(got)) != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if err := NewCommand("exit 3").Notify(completedEvent()); err == nil {
+		t.Error("expected an error for a failing hook, got nil")
+	}
+}
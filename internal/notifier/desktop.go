@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/gen2brain/beeep"
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+// Desktop shows native desktop notifications. Failures are raised as alerts, which
+// additionally play the system's alert sound where supported.
+type Desktop struct {
+	notify func(title, message, appIcon string) error
+	alert  func(title, message, appIcon string) error
+
+	// hyperlinks is set where notifications are delivered over D-Bus, whose servers may
+	// render body markup. macOS and Windows display the body verbatim.
+	hyperlinks bool
+}
+
+func NewDesktop() *Desktop {
+	return &Desktop{
+		notify:     beeep.Notify,
+		alert:      beeep.Alert,
+		hyperlinks: runtime.GOOS != "darwin" && runtime.GOOS != "windows",
+	}
+}
+
+func (d *Desktop) Notify(event checker.Event) error {
+	title, message := desktopMessage(event, d.hyperlinks)
+
+	send := d.notify
+	if event.Failed {
+		send = d.alert
+	}
+
+	if err := send(title, message, ""); err != nil {
+		return fmt.Errorf("failed to send desktop notification: %w", err)
+	}
+
+	return nil
+}
+
+func desktopMessage(event checker.Event, hyperlinks bool) (string, string) {
+	m := newMessage(event)
+	body := m.details()
+
+	switch {
+	case m.URL == "":
+	case hyperlinks:
+		body += fmt.Sprintf("\n<a href=\"%s\">%s</a>", m.URL, m.URL)
+	default:
+		body += "\n" + m.URL
+	}
+
+	return m.title(), body
+}
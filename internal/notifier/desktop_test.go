@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+func Test_Desktop_Notify(t *testing.T) {
+	started := time.Date(2024, 4, 18, 17, 55, 0, 0, time.UTC)
+
+	event := checker.Event{
+		Type: checker.EventCompleted,
+		Pipeline: &gateway.Pipeline{
+			ProjectID:  "26797650",
+			Ref:        "main",
+			Status:     "failed",
+			URL:        "https://gitlab.com/gregfurman/sample-project/-/pipelines/1253625945",
+			StartedAt:  started,
+			FinishedAt: started.Add(72 * time.Second),
+		},
+		Failed:     true,
+		FailedJobs: []gateway.Job{{Name: "lint"}, {Name: "test"}},
+		TestReport: &gateway.TestReport{Total: 12, Failures: []gateway.TestFailure{{Suite: "pkg/foo", Name: "TestFoo"}}},
+	}
+
+	var gotTitle, gotMessage string
+	alerted := false
+
+	d := &Desktop{
+		notify: func(title, message, _ string) error {
+			gotTitle, gotMessage = title, message
+			return nil
+		},
+		alert: func(title, message, _ string) error {
+			gotTitle, gotMessage, alerted = title, message, true
+			return nil
+		},
+	}
+
+	if err := d.Notify(event); err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if !alerted {
+		t.Error("expected failed pipelines to raise an alert")
+	}
+
+	wantTitle := "gregfurman/sample-project (main): pipeline failed"
+	if gotTitle != wantTitle {
+		t.Errorf("expected title %q, got %q", wantTitle, gotTitle)
+	}
+
+	wantMessage := "Status: failed\nDuration: 1m12s\nFailed jobs: lint, test\nFailed tests: pkg/foo: TestFoo\nhttps://gitlab.com/gregfurman/sample-project/-/pipelines/1253625945"
+	if gotMessage != wantMessage {
+		t.Errorf("expected message %q, got %q", wantMessage, gotMessage)
+	}
+}
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+// Message is a flattened view of an event, shared by notifiers and made available to webhook templates.
+type Message struct {
+	Event           string
+	Project         string
+	Ref             string
+	SHA             string
+	PipelineID      int
+	Status          string
+	PreviousStatus  string
+	Failed          bool
+	DurationSeconds int
+	FailedJobs      []string
+	// FailedTests names each failed test as "suite: name".
+	FailedTests []string
+	URL         string
+}
+
+func newMessage(event checker.Event) Message {
+	p := event.Pipeline
+
+	m := Message{
+		Event:           string(event.Type),
+		Project:         p.ProjectName(),
+		Ref:             p.Ref,
+		SHA:             p.CommitSha,
+		PipelineID:      p.ID,
+		Status:          p.Status,
+		PreviousStatus:  event.PreviousStatus,
+		Failed:          event.Failed,
+		DurationSeconds: int(p.Duration().Seconds()),
+		FailedJobs:      []string{},
+		FailedTests:     []string{},
+		URL:             p.URL,
+	}
+
+	if event.TestReport != nil {
+		for _, failure := range event.TestReport.Failures {
+			m.FailedTests = append(m.FailedTests, failure.Suite+": "+failure.Name)
+		}
+	}
+
+	for _, job := range event.FailedJobs {
+		m.FailedJobs = append(m.FailedJobs, job.Name)
+	}
+
+	return m
+}
+
+// maxListedTests is the most failed tests listed in a notification.
+const maxListedTests = 5
+
+func (m Message) title() string {
+	if m.Ref != "" {
+		return fmt.Sprintf("%s (%s): pipeline %s", m.Project, m.Ref, m.Status)
+	}
+
+	return fmt.Sprintf("%s: pipeline %s", m.Project, m.Status)
+}
+
+func (m Message) details() string {
+	lines := []string{fmt.Sprintf("Status: %s", m.Status)}
+	if m.PreviousStatus != "" {
+		lines[0] = fmt.Sprintf("Status: %s → %s", m.PreviousStatus, m.Status)
+	}
+
+	if m.DurationSeconds > 0 {
+		lines = append(lines, fmt.Sprintf("Duration: %s", time.Duration(m.DurationSeconds)*time.Second))
+	}
+
+	if len(m.FailedJobs) > 0 {
+		lines = append(lines, fmt.Sprintf("Failed jobs: %s", strings.Join(m.FailedJobs, ", ")))
+	}
+
+	if n := len(m.FailedTests); n > 0 {
+		listed := m.FailedTests[:min(n, maxListedTests)]
+
+		line := fmt.Sprintf("Failed tests: %s", strings.Join(listed, ", "))
+		if n > len(listed) {
+			line += fmt.Sprintf(" and %d more", n-len(listed))
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// colour returns a hex RGB colour representing the event's outcome.
+func (m Message) colour() string {
+	switch {
+	case m.Failed:
+		return "D73A49"
+	case m.Event == string(checker.EventCompleted):
+		return "28A745"
+	default:
+		return "0366D6"
+	}
+}
@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+type Notifier interface {
+	Notify(event checker.Event) error
+}
+
+type Trigger string
+
+const (
+	// OnFailure notifies only when a pipeline completes unsuccessfully.
+	OnFailure Trigger = "failure"
+
+	// OnSuccess notifies only when a pipeline completes successfully, so not when it is canceled or skipped.
+	OnSuccess Trigger = "success"
+
+	// OnChange notifies on every observed status change, including completion.
+	OnChange Trigger = "change"
+
+	// OnComplete notifies once a pipeline completes, regardless of its outcome.
+	OnComplete Trigger = "complete"
+)
+
+func ParseTrigger(s string) (Trigger, error) {
+	switch t := Trigger(strings.ToLower(s)); t {
+	case OnFailure, OnSuccess, OnChange, OnComplete:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown notification trigger %q: must be one of %s, %s, %s or %s", s, OnFailure, OnSuccess, OnChange, OnComplete)
+	}
+}
+
+// Matches reports whether an event should fire a notification for this trigger.
+func (t Trigger) Matches(event checker.Event) bool {
+	switch t {
+	case OnFailure:
+		return event.Type == checker.EventCompleted && event.Failed
+	case OnSuccess:
+		return event.Type == checker.EventCompleted && event.Succeeded
+	case OnChange:
+		return event.Type == checker.EventStatusChanged || event.Type == checker.EventCompleted
+	case OnComplete:
+		return event.Type == checker.EventCompleted
+	}
+
+	return false
+}
+
+type filtered struct {
+	trigger  Trigger
+	notifier Notifier
+}
+
+// WithTrigger wraps a Notifier so that it only fires for events matching the trigger.
+func WithTrigger(t Trigger, n Notifier) Notifier { //nolint:ireturn
+	return &filtered{trigger: t, notifier: n}
+}
+
+func (f *filtered) Notify(event checker.Event) error {
+	if !f.trigger.Matches(event) {
+		return nil
+	}
+
+	return f.notifier.Notify(event)
+}
+
+// Multi fans an event out to every notifier, returning all errors encountered.
+type Multi []Notifier
+
+func (m Multi) Notify(event checker.Event) error {
+	var errs []error
+
+	for _, n := range m {
+		if err := n.Notify(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
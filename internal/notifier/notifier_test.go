@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+type notifierMock struct {
+	events []checker.Event
+	err    error
+}
+
+func (nm *notifierMock) Notify(event checker.Event) error {
+	nm.events = append(nm.events, event)
+	return nm.err
+}
+
+func Test_Trigger_Matches(t *testing.T) {
+	changed := checker.Event{Type: checker.EventStatusChanged, Pipeline: &gateway.Pipeline{Status: "running"}}
+	succeeded := checker.Event{Type: checker.EventCompleted, Pipeline: &gateway.Pipeline{Status: "success"}, Succeeded: true}
+	canceled := checker.Event{Type: checker.EventCompleted, Pipeline: &gateway.Pipeline{Status: "canceled"}}
+	failed := checker.Event{Type: checker.EventCompleted, Pipeline: &gateway.Pipeline{Status: "failed"}, Failed: true}
+	errored := checker.Event{Type: checker.EventError}
+
+	tests := []struct {
+		trigger Trigger
+		event   checker.Event
+		want    bool
+	}{
+		{trigger: OnFailure, event: changed, want: false},
+		{trigger: OnFailure, event: succeeded, want: false},
+		{trigger: OnFailure, event: failed, want: true},
+		{trigger: OnSuccess, event: changed, want: false},
+		{trigger: OnSuccess, event: succeeded, want: true},
+		{trigger: OnSuccess, event: failed, want: false},
+		{trigger: OnSuccess, event: canceled, want: false},
+		{trigger: OnFailure, event: canceled, want: false},
+		{trigger: OnComplete, event: canceled, want: true},
+		{trigger: OnChange, event: changed, want: true},
+		{trigger: OnChange, event: succeeded, want: true},
+		{trigger: OnChange, event: errored, want: false},
+		{trigger: OnComplete, event: changed, want: false},
+		{trigger: OnComplete, event: succeeded, want: true},
+		{trigger: OnComplete, event: failed, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.trigger)+"/"+string(tt.event.Type), func(ts *testing.T) {
+			if got := tt.trigger.Matches(tt.event); got != tt.want {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ParseTrigger(t *testing.T) {
+	if got, err := ParseTrigger("FAILURE"); err != nil || got != OnFailure {
+		t.Errorf("expected %s, got %s (err=%v)", OnFailure, got, err)
+	}
+
+	if _, err := ParseTrigger("sometimes"); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func Test_Multi_Notify(t *testing.T) {
+	first := &notifierMock{err: errors.New("unreachable")}
+	second := &notifierMock{}
+
+	n := Multi{WithTrigger(OnFailure, first), second}
+
+	if err := n.Notify(checker.Event{Type: checker.EventStatusChanged, Pipeline: &gateway.Pipeline{}}); err != nil {
+		t.Errorf("expected filtered notifier not to fire, got %s", err)
+	}
+
+	if err := n.Notify(checker.Event{Type: checker.EventCompleted, Pipeline: &gateway.Pipeline{}, Failed: true}); err == nil {
+		t.Error("expected an error, got nil")
+	}
+
+	if len(first.events) != 1 || len(second.events) != 2 {
+		t.Errorf("expected 1 and 2 events delivered, got %d and %d", len(first.events), len(second.events))
+	}
+}
@@ -0,0 +1,158 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+// DefaultWebhookTemplate is the JSON body POSTed by generic webhooks when no template is configured.
+const DefaultWebhookTemplate = `{
+  "event": {{ json .Event }},
+  "project": {{ json .Project }},
+  "ref": {{ json .Ref }},
+  "sha": {{ json .SHA }},
+  "pipeline_id": {{ .PipelineID }},
+  "status": {{ json .Status }},
+  "previous_status": {{ json .PreviousStatus }},
+  "failed": {{ .Failed }},
+  "duration_seconds": {{ .DurationSeconds }},
+  "failed_jobs": {{ json .FailedJobs }},
+  "failed_tests": {{ json .FailedTests }},
+  "url": {{ json .URL }}
+}`
+
+const webhookTimeout = 10 * time.Second
+
+// Webhook POSTs a JSON rendering of each event to a URL. Chat services are supported
+// by rendering their incoming webhook payload formats.
+type Webhook struct {
+	url    string
+	client *http.Client
+	render func(Message) (any, error)
+}
+
+// NewWebhook creates a generic webhook whose body is rendered from a text/template. The
+// template may use the "json" function to safely encode values.
+func NewWebhook(url, tmpl string) (*Webhook, error) {
+	if tmpl == "" {
+		tmpl = DefaultWebhookTemplate
+	}
+
+	t, err := template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook template: %w", err)
+	}
+
+	return newWebhook(url, func(m Message) (any, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, m); err != nil {
+			return nil, fmt.Errorf("failed to render webhook template: %w", err)
+		}
+
+		return json.RawMessage(buf.Bytes()), nil
+	}), nil
+}
+
+// NewSlack creates a notifier for a Slack incoming webhook.
+func NewSlack(url string) *Webhook {
+	return newWebhook(url, func(m Message) (any, error) {
+		text := fmt.Sprintf("%s\n%s", m.title(), m.details())
+		if m.URL != "" {
+			text = fmt.Sprintf("<%s|%s>\n%s", m.URL, m.title(), m.details())
+		}
+
+		return map[string]string{"text": text}, nil
+	})
+}
+
+// NewTeams creates a notifier for a Microsoft Teams incoming webhook.
+func NewTeams(url string) *Webhook {
+	return newWebhook(url, func(m Message) (any, error) {
+		card := map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    m.title(),
+			"title":      m.title(),
+			"text":       strings.ReplaceAll(m.details(), "\n", "<br>"),
+			"themeColor": m.colour(),
+		}
+
+		if m.URL != "" {
+			card["potentialAction"] = []map[string]any{{
+				"@type":   "OpenUri",
+				"name":    "View pipeline",
+				"targets": []map[string]string{{"os": "default", "uri": m.URL}},
+			}}
+		}
+
+		return card, nil
+	})
+}
+
+// NewDiscord creates a notifier for a Discord webhook.
+func NewDiscord(url string) *Webhook {
+	return newWebhook(url, func(m Message) (any, error) {
+		colour, _ := strconv.ParseInt(m.colour(), 16, 32)
+
+		return map[string]any{
+			"embeds": []map[string]any{{
+				"title":       m.title(),
+				"url":         m.URL,
+				"description": m.details(),
+				"color":       colour,
+			}},
+		}, nil
+	})
+}
+
+func newWebhook(url string, render func(Message) (any, error)) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		render: render,
+	}
+}
+
+func (w *Webhook) Notify(event checker.Event) error {
+	payload, err := w.render(newMessage(event))
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode template value: %w", err)
+	}
+
+	return string(b), nil
+}
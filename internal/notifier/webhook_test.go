@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+func newReceiver(t *testing.T, status int) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+
+	bodies := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		raw, _ := io.ReadAll(r.Body)
+
+		var body map[string]any
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("expected JSON body, got %s", raw)
+		}
+
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, bodies
+}
+
+func completedEvent() checker.Event {
+	started := time.Date(2024, 4, 18, 17, 55, 0, 0, time.UTC)
+
+	return checker.Event{
+		Type: checker.EventCompleted,
+		Pipeline: &gateway.Pipeline{
+			ID:         1253625945,
+			ProjectID:  "26797650",
+			CommitSha:  "928e2dfffdaaf6fa32f3ee4bf608690b09c6e2c1",
+			Ref:        "main",
+			Status:     "failed",
+			URL:        "https://gitlab.com/gregfurman/sample-project/-/pipelines/1253625945",
+			StartedAt:  started,
+			FinishedAt: started.Add(72 * time.Second),
+		},
+		Failed:     true,
+		FailedJobs: []gateway.Job{{Name: "test"}},
+	}
+}
+
+func Test_Webhook_Notify(t *testing.T) {
+	server, bodies := newReceiver(t, http.StatusOK)
+
+	w, err := NewWebhook(server.URL, "")
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if err := w.Notify(completedEvent()); err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	want := map[string]any{
+		"event":            "completed",
+		"project":          "gregfurman/sample-project",
+		"ref":              "main",
+		"sha":              "928e2dfffdaaf6fa32f3ee4bf608690b09c6e2c1",
+		"pipeline_id":      float64(1253625945),
+		"status":           "failed",
+		"previous_status":  "",
+		"failed":           true,
+		"duration_seconds": float64(72),
+		"failed_jobs":      []any{"test"},
+		"failed_tests":     []any{},
+		"url":              "https://gitlab.com/gregfurman/sample-project/-/pipelines/1253625945",
+	}
+
+	if got := <-bodies; !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_Webhook_CustomTemplate(t *testing.T) {
+	server, bodies := newReceiver(t, http.StatusOK)
+
+	w, err := NewWebhook(server.URL, `{"summary": {{ json (printf "%s is %s" .Project .Status) }}}`)
+	if err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if err := w.Notify(completedEvent()); err != nil {
+		t.Fatalf("unexpected error occurred. expected nil, got %s", err)
+	}
+
+	if got := (<-bodies)["summary"]; got != "gregfurman/sample-project is failed" {
+		t.Errorf("unexpected summary %v", got)
+	}
+
+	if _, err := NewWebhook(server.URL, "{{ .Unclosed "); err == nil {
+		t.Error("expected an error for malformed template, got nil")
+	}
+}
+
+func Test_Webhook_ErrorResponse(t *testing.T) {
+	server, _ := newReceiver(t, http.StatusNotFound)
+
+	if err := NewSlack(server.URL).Notify(completedEvent()); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func Test_ChatWebhooks_Notify(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		new   func(url string) *Webhook
+		field string
+	}{
+		{name: "Slack", new: NewSlack, field: "text"},
+		{name: "Teams", new: NewTeams, field: "@type"},
+		{name: "Discord", new: NewDiscord, field: "embeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			server, bodies := newReceiver(ts, http.StatusNoContent)
+
+			if err := tt.new(server.URL).Notify(completedEvent()); err != nil {
+				ts.Fatalf("unexpected error occurred. expected nil, got %s", err)
+			}
+
+			if _, ok := (<-bodies)[tt.field]; !ok {
+				ts.Errorf("expected payload to contain %q", tt.field)
+			}
+		})
+	}
+
+}
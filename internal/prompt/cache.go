@@ -0,0 +1,191 @@
+// Package prompt caches the status of a repository's HEAD pipeline on disk, so that shell prompts
+// and status bars can show it without waiting on a provider's API.
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is the outcome of a cached pipeline, as shown by its glyph.
+type State string
+
+const (
+	StateSuccess State = "success"
+	StateFailed  State = "failed"
+	StatePending State = "pending"
+
+	// StateNone is recorded when a commit has no pipeline, which may be yet to be created.
+	StateNone State = "none"
+
+	// StateError is recorded when the pipeline could not be retrieved.
+	StateError State = "error"
+)
+
+// completedTTL is how long the status of a completed pipeline is cached. It only changes if the
+// pipeline is retried, so it is refreshed far less often than pending ones.
+const completedTTL = 10 * time.Minute
+
+// lockTimeout is how long a refresh may hold its lock before another is allowed to start.
+const lockTimeout = time.Minute
+
+// Glyph returns the short symbol shown for a state.
+func (s State) Glyph() string {
+	switch s {
+	case StateSuccess:
+		return "✓"
+	case StateFailed:
+		return "✗"
+	case StatePending:
+		return "●"
+	case StateError:
+		return "?"
+	default:
+		return ""
+	}
+}
+
+// Entry is the cached status of a commit's pipeline.
+type Entry struct {
+	State     State     `json:"state"`
+	Status    string    `json:"status,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Fresh reports whether the entry can be shown without refreshing it. Pending, missing and errored
+// pipelines are refreshed once ttl has passed, completed ones far less often.
+func (e Entry) Fresh(now time.Time, ttl time.Duration) bool {
+	if e.State == StateSuccess || e.State == StateFailed {
+		ttl = completedTTL
+	}
+
+	return now.Sub(e.CheckedAt) < ttl
+}
+
+// Cache stores entries as files in a directory, keyed by repository remote and commit.
+type Cache struct {
+	dir string
+	now func() time.Time
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir, now: time.Now}
+}
+
+// DefaultDir returns pipescope's prompt cache directory within the user's cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "pipescope", "prompt"), nil
+}
+
+// Key identifies a commit of a repository.
+func Key(remoteURL, sha string) string {
+	sum := sha256.Sum256([]byte(remoteURL + "\x00" + sha))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// Load returns the cached entry for a key, if any.
+func (c *Cache) Load(key string) (Entry, bool) {
+	b, err := os.ReadFile(c.path(key, ".json"))
+	if err != nil {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return Entry{}, false
+	}
+
+	return entry, true
+}
+
+// Store caches an entry, replacing any existing one.
+func (c *Cache) Store(key string, entry Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode prompt cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create prompt cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so that prompts never read a partially written entry
+	tmp, err := os.CreateTemp(c.dir, key+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write prompt cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write prompt cache entry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write prompt cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key, ".json")); err != nil {
+		return fmt.Errorf("failed to write prompt cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Lock claims the right to refresh a key, so that prompts rendered in quick succession start a
+// single refresh between them. It reports false if another refresh holds the lock, unless that
+// refresh has held it for longer than it should have taken.
+func (c *Cache) Lock(key string) bool {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return false
+	}
+
+	lock := c.path(key, ".lock")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+
+			return true
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return false
+		}
+
+		info, err := os.Stat(lock)
+		if err != nil || c.now().Sub(info.ModTime()) < lockTimeout {
+			return false
+		}
+
+		os.Remove(lock)
+	}
+
+	return false
+}
+
+// Unlock releases a key's refresh lock.
+func (c *Cache) Unlock(key string) {
+	os.Remove(c.path(key, ".lock"))
+}
+
+func (c *Cache) path(key, ext string) string {
+	return filepath.Join(c.dir, key+ext)
+}
@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_Entry_Fresh(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ttl := 15 * time.Second
+
+	tests := []struct {
+		name  string
+		entry Entry
+
+		want bool
+	}{
+		{name: "Recent pending pipeline", entry: Entry{State: StatePending, CheckedAt: now.Add(-5 * time.Second)}, want: true},
+		{name: "Stale pending pipeline", entry: Entry{State: StatePending, CheckedAt: now.Add(-20 * time.Second)}, want: false},
+		{name: "Stale missing pipeline", entry: Entry{State: StateNone, CheckedAt: now.Add(-20 * time.Second)}, want: false},
+		{name: "Completed pipelines are cached for longer", entry: Entry{State: StateFailed, CheckedAt: now.Add(-5 * time.Minute)}, want: true},
+		{name: "Stale completed pipeline", entry: Entry{State: StateSuccess, CheckedAt: now.Add(-time.Hour)}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			if got := tt.entry.Fresh(now, ttl); got != tt.want {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_Cache(t *testing.T) {
+	now := time.Now()
+
+	cache := NewCache(t.TempDir())
+	cache.now = func() time.Time { return now }
+
+	key := Key("https://gitlab.com/group/project.git", "928e2df")
+
+	if _, ok := cache.Load(key); ok {
+		t.Fatal("expected an empty cache")
+	}
+
+	want := Entry{State: StateFailed, Status: "failed", CheckedAt: now.UTC().Truncate(time.Second)}
+	if err := cache.Store(key, want); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got, ok := cache.Load(key); !ok || got != want {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := want.State.Glyph(); got != "✗" {
+		t.Errorf("expected failure glyph, got %q", got)
+	}
+
+	if !cache.Lock(key) {
+		t.Fatal("expected to acquire the refresh lock")
+	}
+
+	if cache.Lock(key) {
+		t.Error("expected a held lock not to be acquired again")
+	}
+
+	// A refresh that never released its lock doesn't block others forever
+	cache.now = func() time.Time { return now.Add(2 * lockTimeout) }
+	if !cache.Lock(key) {
+		t.Error("expected an abandoned lock to be reclaimed")
+	}
+
+	cache.Unlock(key)
+
+	if _, err := os.Stat(cache.path(key, ".lock")); !os.IsNotExist(err) {
+		t.Errorf("expected the lock to be released, got %v", err)
+	}
+}
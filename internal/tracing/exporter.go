@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+const exportTimeout = 10 * time.Second
+
+// Exporter is a notifier.Notifier exporting a trace of every pipeline that completes.
+type Exporter struct {
+	export func(body []byte) error
+}
+
+// NewHTTPExporter creates an exporter POSTing traces to an OTLP/HTTP endpoint. As with
+// OTEL_EXPORTER_OTLP_ENDPOINT, the endpoint is a base URL to which /v1/traces is appended, so
+// http://localhost:4318/otlp exports to http://localhost:4318/otlp/v1/traces. Headers are sent
+// with every request, e.g. to authenticate with a tracing backend.
+func NewHTTPExporter(endpoint string, headers map[string]string) (*Exporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: expected a URL such as http://localhost:4318", endpoint)
+	}
+
+	u = u.JoinPath("v1", "traces")
+
+	client := &http.Client{Timeout: exportTimeout}
+
+	return &Exporter{export: func(body []byte) error {
+		req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create OTLP request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to export trace: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+			return fmt.Errorf("OTLP endpoint responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		}
+
+		return nil
+	}}, nil
+}
+
+// NewFileExporter creates an exporter appending traces to a file, one JSON-encoded request per
+// line, in the format written by the OpenTelemetry Collector's file exporter.
+func NewFileExporter(path string) *Exporter {
+	var mu sync.Mutex
+
+	return &Exporter{export: func(body []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open trace file: %w", err)
+		}
+		defer f.Close()
+
+		if _, err := f.Write(append(body, '\n')); err != nil {
+			return fmt.Errorf("failed to write trace file: %w", err)
+		}
+
+		return nil
+	}}
+}
+
+func (e *Exporter) Notify(event checker.Event) error {
+	if event.Type != checker.EventCompleted {
+		return nil
+	}
+
+	trace, err := Build(event)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(trace)
+	if err != nil {
+		return fmt.Errorf("failed to encode trace: %w", err)
+	}
+
+	return e.export(body)
+}
+
+// ParseHeaders parses headers in the format of OTEL_EXPORTER_OTLP_HEADERS: comma-separated
+// key=value pairs whose values may be URL-encoded.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid OTLP header %q: expected key=value", pair)
+		}
+
+		value, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP header %q: %w", pair, err)
+		}
+
+		headers[strings.TrimSpace(k)] = value
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,204 @@
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+func completedEvent() checker.Event {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return created.Add(time.Duration(seconds) * time.Second) }
+
+	return checker.Event{
+		Type: checker.EventCompleted,
+		Pipeline: &gateway.Pipeline{
+			ID:         42,
+			ProjectID:  "1",
+			CommitSha:  "928e2df",
+			Ref:        "main",
+			Status:     "failed",
+			URL:        "https://gitlab.com/group/project/-/pipelines/42",
+			CreatedAt:  created,
+			StartedAt:  at(10),
+			FinishedAt: at(130),
+		},
+		Failed:     true,
+		FailedJobs: []gateway.Job{{ID: 3, Name: "test", Stage: "test", Status: "failed"}},
+		Jobs: []gateway.Job{
+			{ID: 1, Name: "build", Stage: "build", Status: "success", StartedAt: at(10), FinishedAt: at(60)},
+			{ID: 2, Name: "lint", Stage: "test", Status: "success", StartedAt: at(60), FinishedAt: at(90)},
+			{ID: 3, Name: "test", Stage: "test", Status: "failed", StartedAt: at(65), FinishedAt: at(130)},
+			{ID: 4, Name: "deploy", Stage: "deploy", Status: "skipped"},
+		},
+	}
+}
+
+// collector is an in-process stand-in for an OTLP/HTTP collector, receiving exported traces at path.
+func collector(t *testing.T, path string) (*httptest.Server, <-chan ExportRequest) {
+	t.Helper()
+
+	received := make(chan ExportRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON POST to %s, got %s %s", path, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected configured headers to be sent, got %q", r.Header.Get("Authorization"))
+		}
+
+		var req ExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode export request: %s", err)
+		}
+
+		received <- req
+	}))
+	t.Cleanup(server.Close)
+
+	return server, received
+}
+
+func Test_HTTPExporter(t *testing.T) {
+	server, received := collector(t, "/v1/traces")
+
+	exporter, err := NewHTTPExporter(server.URL, map[string]string{"Authorization": "Bearer secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := exporter.Notify(checker.Event{Type: checker.EventStatusChanged, Pipeline: &gateway.Pipeline{}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := exporter.Notify(completedEvent()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req := <-received
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+
+	type span struct {
+		name, parent string
+		start, end   string
+		code         int
+	}
+
+	names := make(map[string]string, len(spans))
+	for _, s := range spans {
+		names[s.SpanID] = s.Name
+
+		if s.TraceID != spans[0].TraceID || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("expected spans to share a valid trace ID, got %s/%s", s.TraceID, s.SpanID)
+		}
+	}
+
+	got := make([]span, 0, len(spans))
+	for _, s := range spans {
+		got = append(got, span{s.Name, names[s.ParentSpanID], s.StartTimeUnixNano, s.EndTimeUnixNano, s.Status.Code})
+	}
+
+	want := []span{
+		{"pipeline group/project@main", "", "1714564800000000000", "1714564930000000000", statusError},
+		{"build", "stage build", "1714564810000000000", "1714564860000000000", statusOK},
+		{"lint", "stage test", "1714564860000000000", "1714564890000000000", statusOK},
+		{"test", "stage test", "1714564865000000000", "1714564930000000000", statusError},
+		{"stage build", "pipeline group/project@main", "1714564810000000000", "1714564860000000000", statusOK},
+		{"stage test", "pipeline group/project@main", "1714564860000000000", "1714564930000000000", statusError},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected spans\n%v\ngot\n%v", want, got)
+	}
+}
+
+func Test_HTTPExporter_BaseEndpoint(t *testing.T) {
+	server, received := collector(t, "/otlp/v1/traces")
+
+	exporter, err := NewHTTPExporter(server.URL+"/otlp/", map[string]string{"Authorization": "Bearer secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := exporter.Notify(completedEvent()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	<-received
+}
+
+func Test_Build_NoFinishTime(t *testing.T) {
+	event := completedEvent()
+	event.Pipeline.FinishedAt = time.Time{}
+	event.Jobs[2].FinishedAt = time.Time{}
+
+	req, err := Build(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The pipeline ends with the last job to finish, as the test job never did
+	root := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if want := "1714564890000000000"; root.EndTimeUnixNano != want {
+		t.Errorf("expected pipeline to end at %s, got %s", want, root.EndTimeUnixNano)
+	}
+}
+
+func Test_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter := NewFileExporter(path)
+
+	for i := 0; i < 2; i++ {
+		if err := exporter.Notify(completedEvent()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read trace file: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || lines[0] != lines[1] {
+		t.Errorf("expected the same trace to be appended twice, got %s", b)
+	}
+}
+
+func Test_ParseHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "Empty", value: "", want: map[string]string{}},
+		{name: "Pairs", value: "api-key=abc, x-team=ci", want: map[string]string{"api-key": "abc", "x-team": "ci"}},
+		{name: "Encoded value", value: "Authorization=Basic%20dXNlcg%3D%3D", want: map[string]string{"Authorization": "Basic dXNlcg=="}},
+		{name: "Missing value", value: "api-key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			got, err := ParseHeaders(tt.value)
+			if (err != nil) != tt.wantErr {
+				ts.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				ts.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
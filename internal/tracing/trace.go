@@ -0,0 +1,290 @@
+// Package tracing converts completed pipelines into OpenTelemetry traces, exporting them as OTLP
+// JSON so that CI critical paths can be explored in a tracing backend.
+package tracing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+const scopeName = "github.com/gregfurman/pipescope"
+
+const (
+	spanKindInternal = 1
+
+	statusOK    = 1
+	statusError = 2
+)
+
+// ExportRequest is an OTLP ExportTraceServiceRequest in its JSON encoding.
+type ExportRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+type Resource struct {
+	Attributes []Attribute `json:"attributes"`
+}
+
+type ScopeSpans struct {
+	Scope Scope  `json:"scope"`
+	Spans []Span `json:"spans"`
+}
+
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// Span is a single OTLP span. Trace and span IDs are hex-encoded and timestamps are nanoseconds
+// since the Unix epoch, encoded as strings.
+type Span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []Attribute `json:"attributes,omitempty"`
+	Status            Status      `json:"status"`
+}
+
+type Status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type Attribute struct {
+	Key   string `json:"key"`
+	Value Value  `json:"value"`
+}
+
+type Value struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+// stage spans the jobs run in it, from the first to start until the last to finish.
+type stage struct {
+	name       string
+	start, end time.Time
+	failedJob  string
+}
+
+// Build converts a completed pipeline event into a trace: the pipeline is the root span, with a
+// child span for each stage and the jobs as children of their stage. Jobs without a stage, such as
+// GitHub's, are children of the pipeline. Jobs that never started are left out.
+//
+// IDs are derived from the pipeline's URL, so exporting the same pipeline twice produces the same trace.
+func Build(event checker.Event) (ExportRequest, error) {
+	p := event.Pipeline
+
+	start := p.CreatedAt
+	if start.IsZero() {
+		start = p.StartedAt
+	}
+
+	// Pipelines may complete without reporting when they finished, e.g. some canceled ones, in
+	// which case they end with their last job
+	end := p.FinishedAt
+	if end.IsZero() {
+		end = lastJobTime(event.Jobs)
+	}
+
+	if start.IsZero() || end.IsZero() {
+		return ExportRequest{}, errors.New("failed to build trace: pipeline has no start or finish time")
+	}
+
+	traceID := newID(p.URL, strconv.Itoa(p.ID))[:32]
+	rootID := spanID(traceID, "pipeline")
+
+	failed := make(map[int]bool, len(event.FailedJobs))
+	for _, job := range event.FailedJobs {
+		failed[job.ID] = true
+	}
+
+	root := Span{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              "pipeline " + p.ProjectName() + "@" + p.Ref,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []Attribute{
+			stringAttr("cicd.pipeline.run.id", strconv.Itoa(p.ID)),
+			stringAttr("cicd.pipeline.run.url.full", p.URL),
+			stringAttr("vcs.repository.name", p.ProjectName()),
+			stringAttr("vcs.ref.head.name", p.Ref),
+			stringAttr("vcs.ref.head.revision", p.CommitSha),
+			stringAttr("pipescope.status", p.Status),
+			intAttr("pipescope.queued_seconds", int64(p.QueuedDuration().Seconds())),
+		},
+		Status: spanStatus(event.Failed, p.Status),
+	}
+
+	spans := []Span{root}
+	stages := make(map[string]*stage)
+
+	for _, job := range event.Jobs {
+		if job.StartedAt.IsZero() {
+			continue
+		}
+
+		finished := job.FinishedAt
+		if finished.IsZero() {
+			finished = end
+		}
+
+		parentID := rootID
+
+		if job.Stage != "" {
+			st, ok := stages[job.Stage]
+			if !ok {
+				st = &stage{name: job.Stage, start: job.StartedAt, end: finished}
+				stages[job.Stage] = st
+			}
+
+			st.start = minTime(st.start, job.StartedAt)
+			st.end = maxTime(st.end, finished)
+
+			if failed[job.ID] {
+				st.failedJob = job.Name
+			}
+
+			parentID = spanID(traceID, "stage/"+job.Stage)
+		}
+
+		spans = append(spans, Span{
+			TraceID:           traceID,
+			SpanID:            spanID(traceID, "job/"+strconv.Itoa(job.ID)),
+			ParentSpanID:      parentID,
+			Name:              job.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(job.StartedAt),
+			EndTimeUnixNano:   unixNano(finished),
+			Attributes: []Attribute{
+				stringAttr("cicd.pipeline.task.name", job.Name),
+				stringAttr("cicd.pipeline.task.run.id", strconv.Itoa(job.ID)),
+				stringAttr("cicd.pipeline.task.run.url.full", job.URL),
+				stringAttr("pipescope.status", job.Status),
+			},
+			Status: spanStatus(failed[job.ID], job.Status),
+		})
+	}
+
+	ordered := make([]*stage, 0, len(stages))
+	for _, st := range stages {
+		ordered = append(ordered, st)
+	}
+
+	// Stages are ordered by when they started, so the trace's spans are stable
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].start.Equal(ordered[j].start) {
+			return ordered[i].start.Before(ordered[j].start)
+		}
+
+		return ordered[i].name < ordered[j].name
+	})
+
+	for _, st := range ordered {
+		status := Status{Code: statusOK}
+		if st.failedJob != "" {
+			status = Status{Code: statusError, Message: "job " + st.failedJob + " failed"}
+		}
+
+		spans = append(spans, Span{
+			TraceID:           traceID,
+			SpanID:            spanID(traceID, "stage/"+st.name),
+			ParentSpanID:      rootID,
+			Name:              "stage " + st.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(st.start),
+			EndTimeUnixNano:   unixNano(st.end),
+			Attributes:        []Attribute{stringAttr("cicd.pipeline.stage.name", st.name)},
+			Status:            status,
+		})
+	}
+
+	return ExportRequest{ResourceSpans: []ResourceSpans{{
+		Resource: Resource{Attributes: []Attribute{
+			stringAttr("service.name", p.ProjectName()),
+			stringAttr("telemetry.sdk.name", "pipescope"),
+		}},
+		ScopeSpans: []ScopeSpans{{Scope: Scope{Name: scopeName}, Spans: spans}},
+	}}}, nil
+}
+
+func spanStatus(failed bool, status string) Status {
+	if failed {
+		return Status{Code: statusError, Message: status}
+	}
+
+	return Status{Code: statusOK}
+}
+
+// newID hashes its parts into a hex-encoded ID, truncated by callers to the length they need.
+func newID(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func spanID(traceID, name string) string {
+	return newID(traceID, name)[:16]
+}
+
+func stringAttr(key, value string) Attribute {
+	return Attribute{Key: key, Value: Value{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) Attribute {
+	v := strconv.FormatInt(value, 10)
+
+	return Attribute{Key: key, Value: Value{IntValue: &v}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+
+	return a
+}
+
+// lastJobTime returns the latest time any job started or finished, or zero if none started.
+func lastJobTime(jobs []gateway.Job) time.Time {
+	var last time.Time
+
+	for _, job := range jobs {
+		last = maxTime(last, maxTime(job.StartedAt, job.FinishedAt))
+	}
+
+	return last
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+func (r *Receiver) handleGitHub(req *http.Request) ([]checker.Event, int, error) {
+	payload, err := github.ValidatePayload(req, r.secret)
+	if err != nil {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid GitHub signature: %w", err)
+	}
+
+	event, err := github.ParseWebHook(github.WebHookType(req), payload)
+	if err != nil {
+		// Events other than those subscribed to by pipescope, such as pings, are acknowledged and ignored
+		return nil, http.StatusOK, nil //nolint:nilerr
+	}
+
+	switch event := event.(type) {
+	case *github.WorkflowRunEvent:
+		run := event.GetWorkflowRun()
+		if run == nil {
+			return nil, http.StatusBadRequest, errors.New("workflow_run event is missing its workflow run")
+		}
+
+		pipeline := gateway.WorkflowRunToPipeline(run)
+		if pipeline.ProjectID == "" {
+			pipeline.ProjectID = event.GetRepo().GetFullName()
+		}
+
+		return r.update(githubKey(event.GetRepo().GetFullName(), run.GetID()), pipeline, githubStatuses, nil), http.StatusOK, nil
+	case *github.WorkflowJobEvent:
+		job := event.GetWorkflowJob()
+		if job == nil {
+			return nil, http.StatusBadRequest, errors.New("workflow_job event is missing its workflow job")
+		}
+
+		r.recordJob(githubKey(event.GetRepo().GetFullName(), job.GetRunID()), gateway.WorkflowJobToJob(job))
+	}
+
+	return nil, http.StatusOK, nil
+}
+
+func githubKey(repo string, runID int64) string {
+	return "github/" + repo + "/" + strconv.FormatInt(runID, 10)
+}
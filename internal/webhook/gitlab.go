@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+	"github.com/xanzy/go-gitlab"
+)
+
+// GitLab formats hook timestamps differently from its API.
+var gitlabTimeLayouts = []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700", time.RFC3339}
+
+func (r *Receiver) handleGitLab(req *http.Request) ([]checker.Event, int, error) {
+	payload, err := readBody(req)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read GitLab hook: %w", err)
+	}
+
+	event, err := gitlab.ParseWebhook(gitlab.HookEventType(req), payload)
+	if err != nil {
+		// Hooks other than those subscribed to by pipescope are acknowledged and ignored
+		return nil, http.StatusOK, nil //nolint:nilerr
+	}
+
+	switch event := event.(type) {
+	case *gitlab.PipelineEvent:
+		pipeline := gitlabPipeline(event)
+
+		var jobs []gateway.Job
+		for _, build := range event.Builds {
+			jobs = append(jobs, gateway.Job{
+				ID:         build.ID,
+				Name:       build.Name,
+				Stage:      build.Stage,
+				Status:     build.Status,
+				URL:        fmt.Sprintf("%s/-/jobs/%d", event.Project.WebURL, build.ID),
+				StartedAt:  parseGitLabTime(build.StartedAt),
+				FinishedAt: parseGitLabTime(build.FinishedAt),
+			})
+		}
+
+		key := gitlabKey(event.Project.ID, event.ObjectAttributes.ID)
+
+		return r.update(key, pipeline, gitlabStatuses, jobs), http.StatusOK, nil
+	case *gitlab.JobEvent:
+		job := gateway.Job{
+			ID:         event.BuildID,
+			Name:       event.BuildName,
+			Stage:      event.BuildStage,
+			Status:     event.BuildStatus,
+			StartedAt:  parseGitLabTime(event.BuildStartedAt),
+			FinishedAt: parseGitLabTime(event.BuildFinishedAt),
+		}
+
+		if event.Repository != nil {
+			job.URL = fmt.Sprintf("%s/-/jobs/%d", event.Repository.Homepage, event.BuildID)
+		}
+
+		r.recordJob(gitlabKey(event.ProjectID, event.PipelineID), job)
+	}
+
+	return nil, http.StatusOK, nil
+}
+
+func gitlabPipeline(event *gitlab.PipelineEvent) *gateway.Pipeline {
+	attrs := event.ObjectAttributes
+
+	url := attrs.URL
+	if url == "" {
+		url = fmt.Sprintf("%s/-/pipelines/%d", strings.TrimSuffix(event.Project.WebURL, "/"), attrs.ID)
+	}
+
+	pipeline := &gateway.Pipeline{
+		ID:         attrs.ID,
+		ProjectID:  strconv.Itoa(event.Project.ID),
+		CommitSha:  attrs.SHA,
+		Ref:        attrs.Ref,
+		Status:     attrs.Status,
+		URL:        url,
+		CreatedAt:  parseGitLabTime(attrs.CreatedAt),
+		FinishedAt: parseGitLabTime(attrs.FinishedAt),
+	}
+
+	// Hooks carry no start time, but the duration is known once the pipeline finishes
+	if !pipeline.FinishedAt.IsZero() && attrs.Duration > 0 {
+		pipeline.StartedAt = pipeline.FinishedAt.Add(-time.Duration(attrs.Duration) * time.Second)
+	}
+
+	return pipeline
+}
+
+func gitlabKey(projectID, pipelineID int) string {
+	return "gitlab/" + strconv.Itoa(projectID) + "/" + strconv.Itoa(pipelineID)
+}
+
+func parseGitLabTime(value string) time.Time {
+	for _, layout := range gitlabTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,216 @@
+// Package webhook receives pipeline events pushed by GitLab and GitHub webhooks, producing the
+// same event stream as polling without making any API requests.
+package webhook
+
+import (
+	"crypto/subtle"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/gateway"
+)
+
+// eventBuffer is the number of events buffered before webhook deliveries wait for them to be read.
+const eventBuffer = 64
+
+// statusSuccess is the status of successful pipelines on both GitLab and GitHub.
+const statusSuccess = "success"
+
+const (
+	// stateTTL is how long a pipeline's state is kept after its last delivery. Completed pipelines
+	// are kept too, so that redelivered and late hooks don't report them again, and pipelines whose
+	// completion was never delivered don't accumulate.
+	stateTTL = 24 * time.Hour
+
+	// sweepInterval is how often expired pipeline states are evicted.
+	sweepInterval = time.Minute
+)
+
+// statuses classifies a provider's pipeline and job statuses.
+type statuses interface {
+	IsStatusPending(status string) bool
+	IsStatusFailed(status string) bool
+}
+
+var (
+	githubStatuses statuses = &gateway.GitHubClient{}
+	gitlabStatuses statuses = &gateway.GitLabClient{}
+)
+
+// pipelineState is what has been received of a pipeline.
+type pipelineState struct {
+	status string
+	jobs   []gateway.Job
+	// updatedAt is when the last delivery for the pipeline was received.
+	updatedAt time.Time
+}
+
+// Receiver is an http.Handler accepting GitLab pipeline and job hooks, and GitHub workflow_run and
+// workflow_job events. Pipeline updates are emitted as checker.Events: an EventStatusChanged for
+// every status change followed by an EventCompleted once the pipeline finishes, listing the
+// jobs received for it.
+type Receiver struct {
+	secret []byte
+	events chan checker.Event
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	pipelines map[string]*pipelineState
+	lastSweep time.Time
+}
+
+// NewReceiver creates a Receiver verifying deliveries with the given secret: the HMAC signature
+// of GitHub deliveries and the X-Gitlab-Token header of GitLab deliveries. An empty secret
+// accepts every delivery.
+func NewReceiver(secret string) *Receiver {
+	return &Receiver{
+		secret:    []byte(secret),
+		events:    make(chan checker.Event, eventBuffer),
+		now:       time.Now,
+		pipelines: make(map[string]*pipelineState),
+	}
+}
+
+// Events returns the channel the received pipeline events are sent to.
+func (r *Receiver) Events() <-chan checker.Event {
+	return r.events
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		events []checker.Event
+		status int
+		err    error
+	)
+
+	switch {
+	case req.Header.Get("X-GitHub-Event") != "":
+		events, status, err = r.handleGitHub(req)
+	case req.Header.Get("X-Gitlab-Event") != "":
+		if len(r.secret) > 0 && subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Gitlab-Token")), r.secret) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		events, status, err = r.handleGitLab(req)
+	default:
+		http.Error(w, "unrecognised webhook: expected a GitHub or GitLab delivery", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		slog.Debug("rejected webhook delivery", slog.Any("error", err))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	for _, event := range events {
+		r.events <- event
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// update records a pipeline's latest status, returning the events it produces. Jobs replace
+// those already recorded for the pipeline unless nil.
+func (r *Receiver) update(key string, pipeline *gateway.Pipeline, s statuses, jobs []gateway.Job) []checker.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.state(key)
+
+	if jobs != nil {
+		state.jobs = jobs
+	}
+
+	// Redelivered or reordered hooks don't change the status
+	if pipeline.Status == state.status {
+		return nil
+	}
+
+	failed := s.IsStatusFailed(pipeline.Status)
+	succeeded := pipeline.Status == statusSuccess
+	events := []checker.Event{{
+		Type:           checker.EventStatusChanged,
+		Pipeline:       pipeline,
+		PreviousStatus: state.status,
+		Failed:         failed,
+		Succeeded:      succeeded,
+	}}
+
+	state.status = pipeline.Status
+
+	if s.IsStatusPending(pipeline.Status) {
+		return events
+	}
+
+	completed := checker.Event{Type: checker.EventCompleted, Pipeline: pipeline, Failed: failed, Succeeded: succeeded && !failed, Jobs: state.jobs}
+	if failed {
+		for _, job := range state.jobs {
+			if s.IsStatusFailed(job.Status) {
+				completed.FailedJobs = append(completed.FailedJobs, job)
+			}
+		}
+	}
+
+	return append(events, completed)
+}
+
+// recordJob records a job of a pipeline, to be listed once the pipeline completes.
+func (r *Receiver) recordJob(key string, job gateway.Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.state(key)
+
+	// Retried jobs keep their name, so only their latest attempt is kept
+	jobs := state.jobs[:0:0]
+	for _, j := range state.jobs {
+		if j.Name != job.Name {
+			jobs = append(jobs, j)
+		}
+	}
+
+	state.jobs = append(jobs, job)
+}
+
+// state returns the recorded state of a pipeline, creating it if there is none, and evicts the
+// states of pipelines without deliveries for stateTTL. It must be called with the lock held.
+func (r *Receiver) state(key string) *pipelineState {
+	now := r.now()
+
+	if now.Sub(r.lastSweep) >= sweepInterval {
+		for k, state := range r.pipelines {
+			if now.Sub(state.updatedAt) > stateTTL {
+				delete(r.pipelines, k)
+			}
+		}
+
+		r.lastSweep = now
+	}
+
+	state, ok := r.pipelines[key]
+	if !ok {
+		state = &pipelineState{}
+		r.pipelines[key] = state
+	}
+
+	state.updatedAt = now
+
+	return state
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(req.Body, 25<<20))
+}
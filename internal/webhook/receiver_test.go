@@ -0,0 +1,288 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+)
+
+const secret = "s3cret"
+
+func githubRequest(event, body, key string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(body))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	return req
+}
+
+func gitlabRequest(event, body, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitlab-Event", event)
+	req.Header.Set("X-Gitlab-Token", token)
+
+	return req
+}
+
+func workflowRun(status, conclusion string) string {
+	return `{
+		"action": "` + status + `",
+		"workflow_run": {
+			"id": 30433642,
+			"head_branch": "main",
+			"head_sha": "acb5820ced9479c074f688cc328bf03f341a511d",
+			"status": "` + status + `",
+			"conclusion": ` + conclusion + `,
+			"html_url": "https://github.com/octo-org/octo-repo/actions/runs/30433642",
+			"repository": {"full_name": "octo-org/octo-repo"}
+		},
+		"repository": {"full_name": "octo-org/octo-repo"}
+	}`
+}
+
+func gitlabPipelineHook(status string) string {
+	return `{
+		"object_kind": "pipeline",
+		"object_attributes": {
+			"id": 31,
+			"ref": "main",
+			"sha": "bcbb5ec396a2c0f828686f14fac9b80b780504f2",
+			"status": "` + status + `",
+			"finished_at": "2016-08-12 15:26:29 UTC",
+			"duration": 63
+		},
+		"project": {"id": 1, "web_url": "http://192.168.64.1:3005/gitlab-org/gitlab-test"},
+		"builds": [
+			{"id": 380, "stage": "deploy", "name": "production", "status": "skipped"},
+			{"id": 377, "stage": "test", "name": "test-image", "status": "failed"}
+		]
+	}`
+}
+
+func serve(ts *testing.T, receiver *Receiver, req *http.Request) int {
+	ts.Helper()
+
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, req)
+
+	return recorder.Code
+}
+
+func drain(receiver *Receiver) []checker.Event {
+	var events []checker.Event
+
+	for {
+		select {
+		case event := <-receiver.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func Test_Receiver_GitHub(t *testing.T) {
+	receiver := NewReceiver(secret)
+
+	requests := []*http.Request{
+		githubRequest("workflow_run", workflowRun("in_progress", "null"), secret),
+		githubRequest("workflow_job", `{
+			"action": "completed",
+			"workflow_job": {"id": 29679449, "run_id": 30433642, "name": "test", "status": "completed", "conclusion": "failure"},
+			"repository": {"full_name": "octo-org/octo-repo"}
+		}`, secret),
+		githubRequest("workflow_run", workflowRun("completed", `"failure"`), secret),
+	}
+
+	for _, req := range requests {
+		if code := serve(t, receiver, req); code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+		}
+	}
+
+	events := drain(receiver)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %v", len(events), events)
+	}
+
+	if events[0].Type != checker.EventStatusChanged || events[0].Pipeline.Status != "in_progress" {
+		t.Errorf("expected in_progress status event, got %v", events[0])
+	}
+
+	if events[1].Type != checker.EventStatusChanged || events[1].PreviousStatus != "in_progress" || events[1].Pipeline.Status != "failure" {
+		t.Errorf("expected in_progress to failure status event, got %v", events[1])
+	}
+
+	completed := events[2]
+	if completed.Type != checker.EventCompleted || !completed.Failed || completed.Pipeline.ProjectID != "octo-org/octo-repo" {
+		t.Errorf("expected failed completion event, got %v", completed)
+	}
+
+	if len(completed.FailedJobs) != 1 || completed.FailedJobs[0].Name != "test" {
+		t.Errorf("expected failed job 'test', got %v", completed.FailedJobs)
+	}
+}
+
+func Test_Receiver_GitLab(t *testing.T) {
+	receiver := NewReceiver(secret)
+
+	for _, status := range []string{"running", "running", "failed"} {
+		if code := serve(t, receiver, gitlabRequest("Pipeline Hook", gitlabPipelineHook(status), secret)); code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+		}
+	}
+
+	events := drain(receiver)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %v", len(events), events)
+	}
+
+	completed := events[2]
+	if completed.Type != checker.EventCompleted || !completed.Failed {
+		t.Errorf("expected failed completion event, got %v", completed)
+	}
+
+	if completed.Pipeline.URL != "http://192.168.64.1:3005/gitlab-org/gitlab-test/-/pipelines/31" || completed.Pipeline.Duration().Seconds() != 63 {
+		t.Errorf("expected pipeline URL and duration to be set, got %v", completed.Pipeline)
+	}
+
+	if len(completed.FailedJobs) != 1 || completed.FailedJobs[0].URL != "http://192.168.64.1:3005/gitlab-org/gitlab-test/-/jobs/377" {
+		t.Errorf("expected failed job 'test-image', got %v", completed.FailedJobs)
+	}
+}
+
+func Test_Receiver_Succeeded(ts *testing.T) {
+	tests := []struct {
+		name      string
+		requests  []*http.Request
+		succeeded bool
+	}{
+		{
+			name:      "GitHub successful run",
+			requests:  []*http.Request{githubRequest("workflow_run", workflowRun("completed", `"success"`), secret)},
+			succeeded: true,
+		},
+		{
+			name:     "GitHub canceled run",
+			requests: []*http.Request{githubRequest("workflow_run", workflowRun("completed", `"cancelled"`), secret)},
+		},
+		{
+			name:      "GitLab successful pipeline",
+			requests:  []*http.Request{gitlabRequest("Pipeline Hook", gitlabPipelineHook("success"), secret)},
+			succeeded: true,
+		},
+		{
+			name:     "GitLab canceled pipeline",
+			requests: []*http.Request{gitlabRequest("Pipeline Hook", gitlabPipelineHook("canceled"), secret)},
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func(t *testing.T) {
+			receiver := NewReceiver(secret)
+
+			for _, req := range test.requests {
+				if code := serve(t, receiver, req); code != http.StatusNoContent {
+					t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+				}
+			}
+
+			events := drain(receiver)
+			if len(events) != 2 || events[1].Type != checker.EventCompleted {
+				t.Fatalf("expected status change and completion events, got %v", events)
+			}
+
+			if events[1].Succeeded != test.succeeded {
+				t.Errorf("expected succeeded %t, got %t", test.succeeded, events[1].Succeeded)
+			}
+		})
+	}
+}
+
+func Test_Receiver_LateDeliveries(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	receiver := NewReceiver(secret)
+	receiver.now = func() time.Time { return now }
+
+	lateJob := `{
+		"object_kind": "build",
+		"build_id": 377,
+		"build_name": "test-image",
+		"build_stage": "test",
+		"build_status": "failed",
+		"pipeline_id": 31,
+		"project_id": 1
+	}`
+
+	// The completion is redelivered, and a job hook arrives after the pipeline hook
+	requests := []*http.Request{
+		gitlabRequest("Pipeline Hook", gitlabPipelineHook("running"), secret),
+		gitlabRequest("Pipeline Hook", gitlabPipelineHook("failed"), secret),
+		gitlabRequest("Pipeline Hook", gitlabPipelineHook("failed"), secret),
+		gitlabRequest("Job Hook", lateJob, secret),
+	}
+
+	for _, req := range requests {
+		if code := serve(t, receiver, req); code != http.StatusNoContent && code != http.StatusOK {
+			t.Fatalf("expected a successful status, got %d", code)
+		}
+	}
+
+	events := drain(receiver)
+	if len(events) != 3 || events[2].Type != checker.EventCompleted {
+		t.Fatalf("expected the pipeline to complete once, got %v", events)
+	}
+
+	// Pipelines without deliveries for a day are forgotten
+	now = now.Add(stateTTL + time.Minute)
+
+	if code := serve(t, receiver, githubRequest("workflow_run", workflowRun("in_progress", "null"), secret)); code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+	}
+
+	if _, ok := receiver.pipelines[gitlabKey(1, 31)]; ok || len(receiver.pipelines) != 1 {
+		t.Errorf("expected only the latest pipeline to be recorded, got %v", receiver.pipelines)
+	}
+}
+
+func Test_Receiver_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+
+		want int
+	}{
+		{name: "GitHub delivery with the wrong signature", req: githubRequest("workflow_run", workflowRun("in_progress", "null"), "wrong"), want: http.StatusUnauthorized},
+		{name: "GitLab delivery with the wrong token", req: gitlabRequest("Pipeline Hook", gitlabPipelineHook("running"), "wrong"), want: http.StatusUnauthorized},
+		{name: "Unrecognised delivery", req: httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")), want: http.StatusBadRequest},
+		{name: "Non-POST request", req: httptest.NewRequest(http.MethodGet, "/", nil), want: http.StatusMethodNotAllowed},
+		{name: "Unsubscribed events are ignored", req: githubRequest("push", `{}`, secret), want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(ts *testing.T) {
+			receiver := NewReceiver(secret)
+
+			if got := serve(ts, receiver, tt.req); got != tt.want {
+				ts.Errorf("expected status %d, got %d", tt.want, got)
+			}
+
+			if events := drain(receiver); len(events) != 0 {
+				ts.Errorf("expected no events, got %v", events)
+			}
+		})
+	}
+}
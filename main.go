@@ -1,108 +1,300 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/gen2brain/beeep"
 	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
 	"github.com/gregfurman/pipescope/internal/gateway"
 	"github.com/gregfurman/pipescope/internal/git"
+	"github.com/gregfurman/pipescope/internal/metrics"
+	"github.com/gregfurman/pipescope/internal/notifier"
 )
 
+// commands are run in place of watching the HEAD pipeline when named as the first argument.
+var commands = map[string]func(args []string) error{
+	"artifacts":     runArtifacts,
+	"auth":          runAuth,
+	"daemon":        runDaemon,
+	"list":          runList,
+	"mr":            runMergeRequest,
+	"open":          runOpen,
+	"prompt":        runPrompt,
+	"serve-webhook": runServeWebhook,
+}
+
 func main() {
-	faccessToken := flag.String("access-token", setFromEnv("ACCESS_TOKEN", ""), "API access token where remote pipeline resides (env=ACCESS_TOKEN).")
-	fgitDirectoryLoc := flag.String("git-directory", ".", "Location of .git directory.")
-	fpollFrequency := flag.Duration("poll-frequency", 5*time.Second, "Polling frequency to pipeline.")
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				exit(err)
+			}
+
+			return
+		}
+	}
 
-	// Experimental
-	fplaySoundOnComplete := flag.Bool("play-sound", false, "Play a noise when pipeline completes (experimental).")
+	opts := newOptions(flag.CommandLine)
 
 	flag.Parse()
 
+	opts.provider = flag.Arg(0)
+
+	if err := watch(opts); err != nil {
+		exit(err)
+	}
+}
+
+// runMergeRequest watches the pipeline of the checked out branch's open merge request, as -pr does.
+func runMergeRequest(args []string) error {
+	fs := flag.NewFlagSet("mr", flag.ExitOnError)
+	opts := newOptions(fs)
+
+	fs.Parse(args) //nolint:errcheck
+
+	opts.provider = fs.Arg(0)
+	opts.mergeRequest = true
+
+	return watch(opts)
+}
+
+// watch watches the HEAD pipeline, or the merge request pipeline with -pr, until it completes.
+func watch(opts *options) error {
 	// Define clients
-	gitClient, err := git.New(*fgitDirectoryLoc)
+	gitClient, err := git.New(opts.gitDirectory)
 	if err != nil {
-		exit(err)
+		return err
 	}
 
-	var gatewayClient gateway.Client
+	// The remote is only needed to pick a provider and resolve config, so failures are tolerated here
+	url, _ := gitClient.GetRemoteURL()
 
-	switch {
-	// If an arg is passed in, use it to determine the git provider
-	case flag.Arg(0) != "":
-		gatewayClient, err = gateway.New(*faccessToken, gateway.ProviderType(flag.Arg(0)))
-	// Check the access token's prefix to determine the git provider
-	case *faccessToken != "":
-		gatewayClient, err = gateway.NewFromToken(*faccessToken)
-	// Check the remote git URL to determine the git provider
-	default:
-		url, _ := gitClient.GetRemoteURL()
-		gatewayClient, err = gateway.NewFromRemoteURL(*faccessToken, url)
+	cfg, err := config.Load(opts.configPath, opts.gitDirectory)
+	if err != nil {
+		return err
 	}
 
+	opts.applySettings(cfg.Resolve(gateway.ParseRemoteURL(url)))
+
+	if err := opts.setupLogger(); err != nil {
+		return err
+	}
+
+	if opts.metricsAddr != "" {
+		opts.metrics = metrics.New()
+		opts.serveMetrics()
+	}
+
+	notifiers, err := opts.notifiers()
 	if err != nil {
-		exit(err)
+		return err
+	}
+
+	gatewayClient, err := opts.newGatewayClient(cfg, url)
+	if err != nil {
+		return err
+	}
+
+	if opts.validateToken {
+		warnings, err := gateway.Preflight(gatewayClient)
+		if err != nil {
+			return err
+		}
+
+		for _, warning := range warnings {
+			slog.Warn(warning)
+		}
 	}
 
 	// Define service
-	svc := checker.New(gatewayClient, gitClient)
+	svc := opts.newService(gatewayClient, gitClient)
 
 	// Run polling
-	if err := run(svc, *fpollFrequency); err != nil {
-		exit(err)
+	if opts.mergeRequest {
+		err = runMergeRequestPipeline(svc, opts.scheduler(svc), notifiers)
+	} else {
+		err = run(svc, opts.scheduler(svc), notifiers)
+	}
+
+	if err != nil {
+		return err
 	}
 
-	if *fplaySoundOnComplete {
+	if opts.playSound {
 		if err := beeep.Beep(beeep.DefaultFreq, beeep.DefaultDuration); err != nil {
 			slog.Error("error encountered when playing sound", slog.Any("error", err))
 		}
 	}
+
+	return nil
 }
 
-func run(svc *checker.Service, pollFrequency time.Duration) error {
+func run(svc *checker.Service, schedule func(*gateway.Pipeline) checker.Scheduler, n notifier.Notifier) error {
 	pipeline, err := svc.GetPipeline()
 	if err != nil {
 		return fmt.Errorf("checker Service GET pipeline failed: %w", err)
 	}
 
-	status := pipeline.Status
+	logger := pipelineLogger(pipeline)
+
+	for event := range svc.WatchPipeline(pipeline, schedule(pipeline)) {
+		report(logger, event, n)
+	}
+
+	return nil
+}
+
+// runMergeRequestPipeline watches the pipeline of the checked out branch's open merge request,
+// summarising whether it can be merged once the pipeline completes.
+func runMergeRequestPipeline(svc *checker.Service, schedule func(*gateway.Pipeline) checker.Scheduler, n notifier.Notifier) error {
+	mr, pipeline, err := svc.GetMergeRequestPipeline()
+	if err != nil {
+		return err
+	}
+
+	logger := pipelineLogger(pipeline).With(slog.Int("merge_request", mr.ID), slog.Any("merge_request_url", mr.URL))
+	logger.Info(fmt.Sprintf("Watching Merge Request [title=%s, source=%s, target=%s]", mr.Title, mr.SourceBranch, mr.TargetBranch))
+
+	svc.WithCoverageBase(mr.TargetBranch)
+
+	for event := range svc.WatchPipeline(pipeline, schedule(pipeline)) {
+		report(logger, event, n)
+	}
+
+	status, err := svc.MergeStatus(mr)
+	if err != nil {
+		logger.Warn(err.Error())
+
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("Merge Status [mergeable=%t, detail=%s]", status.Mergeable, status.Detail))
 
-	slog.Group("pipeline")
+	for _, check := range status.RequiredChecks {
+		logger.Info(fmt.Sprintf("Required Check [name=%s, status=%s]", check.Name, check.Status), slog.Any("check_url", check.URL))
+	}
 
-	logger := slog.New(slog.Default().Handler()).With(
+	return nil
+}
+
+func pipelineLogger(pipeline *gateway.Pipeline) *slog.Logger {
+	return slog.New(slog.Default().Handler()).With(
 		slog.Any("url", pipeline.URL),
 		slog.Any("sha", pipeline.CommitSha),
 		slog.Any("project_id", pipeline.ProjectID),
 		slog.Any("pipeline_id", pipeline.ID),
 	)
+}
+
+// report logs a pipeline event and sends it to the notifiers.
+func report(logger *slog.Logger, event checker.Event, n notifier.Notifier) {
+	switch event.Type {
+	case checker.EventStatusChanged:
+		logger.Info(fmt.Sprintf("Polled Pipeline [status=%s]", event.Pipeline.Status))
+	case checker.EventDownstreamChanged:
+		logger.Info(fmt.Sprintf("Polled Downstream Pipeline [bridge=%s, status=%s]", event.Bridge, event.Pipeline.Status),
+			slog.Int("downstream_pipeline_id", event.Pipeline.ID), slog.Any("downstream_pipeline_url", event.Pipeline.URL))
+	case checker.EventCompleted:
+		for _, downstream := range event.Downstream {
+			reportDownstream(logger, downstream)
+		}
 
-	logger.Info(fmt.Sprintf("Polled Pipeline [status=%s]", pipeline.Status))
+		for _, job := range event.FailedJobs {
+			logger.Info(fmt.Sprintf("Failed Job [name=%s]", job.Name), slog.Any("job_url", job.URL))
+		}
 
-	statusCh, _ := svc.PollPipelineStatus(pipeline.ProjectID, pipeline.ID, pollFrequency)
-	for s := range statusCh {
-		if s == "" {
-			continue
+		for _, excerpt := range event.Excerpts {
+			reportExcerpt(logger, excerpt)
 		}
 
-		if s != status {
-			status = s
-			logger.Info(fmt.Sprintf("Polled Pipeline [status=%s]", s))
+		if event.TestReport != nil {
+			reportTests(logger, event.TestReport)
 		}
+
+		if event.Coverage != nil {
+			reportCoverage(logger, event.Coverage)
+		}
+	case checker.EventError:
+		logger.Error(event.Err.Error())
 	}
 
-	return nil
+	if err := n.Notify(event); err != nil {
+		slog.Error("error encountered when sending notification", slog.Any("error", err))
+	}
 }
 
-func setFromEnv(name, defaultValue string) string {
-	if v, ok := os.LookupEnv(name); ok {
-		return v
+// reportExcerpt logs the errors extracted from a failed job's log, indented beneath a summary line.
+func reportExcerpt(logger *slog.Logger, excerpt checker.JobExcerpt) {
+	lines := strings.ReplaceAll("\n"+excerpt.Excerpt.String(), "\n", "\n    ")
+
+	logger.Info(fmt.Sprintf("Log Excerpt [job=%s, extractor=%s]%s", excerpt.Job.Name, excerpt.Excerpt.Extractor, lines))
+}
+
+// reportCoverage logs the pipeline's coverage and its change from the base branch, followed by the
+// coverage of each job.
+func reportCoverage(logger *slog.Logger, report *checker.CoverageReport) {
+	attrs := []any{slog.Float64("coverage", report.Percent), slog.String("base_ref", report.BaseRef)}
+	summary := fmt.Sprintf("Coverage [percent=%.2f%%]", report.Percent)
+	level := slog.LevelInfo
+
+	if delta, ok := report.Delta(); ok {
+		attrs = append(attrs, slog.Float64("base_coverage", report.Base.Percent), slog.Float64("coverage_delta", delta))
+		summary = fmt.Sprintf("Coverage [percent=%.2f%%, delta=%+.2f%% against %s]", report.Percent, delta, report.BaseRef)
+
+		// Regressions are warned about so they stand out
+		if delta < 0 {
+			level = slog.LevelWarn
+		}
+	}
+
+	logger.Log(context.Background(), level, summary, attrs...)
+
+	for _, job := range report.Jobs {
+		logger.Info(fmt.Sprintf("Job Coverage [name=%s, percent=%.2f%%]", job.Name, job.Percent))
+	}
+}
+
+// reportTests logs how many tests failed in each suite, followed by every failed test.
+func reportTests(logger *slog.Logger, report *gateway.TestReport) {
+	for _, suite := range report.FailedSuites() {
+		tests := "tests"
+		if suite.Failed == 1 {
+			tests = "test"
+		}
+
+		logger.Info(fmt.Sprintf("%d %s failed in %s", suite.Failed, tests, suite.Suite))
+	}
+
+	for _, failure := range report.Failures {
+		logger.Info(fmt.Sprintf("Failed Test [suite=%s, name=%s]", failure.Suite, failure.Name), slog.String("message", firstLine(failure.Message)))
 	}
+}
 
-	return defaultValue
+// firstLine returns the first non-blank line of a message, such as a test's failure message or stack trace.
+func firstLine(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+
+	return ""
+}
+
+// reportDownstream logs a downstream pipeline and, recursively, the pipelines it triggered.
+func reportDownstream(logger *slog.Logger, tree gateway.PipelineTree) {
+	logger.Info(fmt.Sprintf("Downstream Pipeline [bridge=%s, status=%s]", tree.Bridge, tree.Pipeline.Status),
+		slog.Int("downstream_pipeline_id", tree.Pipeline.ID), slog.Any("downstream_pipeline_url", tree.Pipeline.URL))
+
+	for _, child := range tree.Downstream {
+		reportDownstream(logger, child)
+	}
 }
 
 func exit(err error) {
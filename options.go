@@ -0,0 +1,416 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gregfurman/pipescope/internal/checker"
+	"github.com/gregfurman/pipescope/internal/config"
+	"github.com/gregfurman/pipescope/internal/excerpt"
+	"github.com/gregfurman/pipescope/internal/gateway"
+	"github.com/gregfurman/pipescope/internal/git"
+	"github.com/gregfurman/pipescope/internal/metrics"
+	"github.com/gregfurman/pipescope/internal/notifier"
+	"github.com/gregfurman/pipescope/internal/tracing"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+const (
+	githubSourceActions = "actions"
+	githubSourceChecks  = "checks"
+)
+
+type options struct {
+	accessToken     string
+	gitDirectory    string
+	configPath      string
+	provider        string
+	output          string
+	debug           bool
+	pollFrequency   time.Duration
+	adaptivePoll    bool
+	maxPollInterval time.Duration
+	notify          config.Notify
+	playSound       bool
+	validateToken   bool
+	metricsAddr     string
+	mergeRequest    bool
+	githubSource    string
+	excerptLines    int
+	downstreamWait  time.Duration
+	trace           config.Trace
+
+	// settingsToken is the config file's access_token, which per-host tokens take precedence over.
+	settingsToken string
+
+	// metrics records pipeline and API metrics when they are served.
+	metrics *metrics.Registry
+
+	flags *flag.FlagSet
+	env   map[string]string
+}
+
+func newOptions(fs *flag.FlagSet) *options {
+	o := &options{
+		flags: fs,
+		env:   make(map[string]string),
+	}
+
+	fs.StringVar(&o.accessToken, "access-token", o.fromEnv("access-token", "ACCESS_TOKEN", ""), "API access token where remote pipeline resides (env=ACCESS_TOKEN).")
+	fs.StringVar(&o.gitDirectory, "git-directory", ".", "Location of .git directory.")
+	fs.StringVar(&o.configPath, "config", o.fromEnv("config", "PIPESCOPE_CONFIG", defaultConfigPath()), "Path to the pipescope config file (env=PIPESCOPE_CONFIG).")
+	fs.StringVar(&o.output, "output", outputText, "Output format: one of text or json.")
+	fs.BoolVar(&o.debug, "debug", false, "Enable debug logging, including the remaining API rate limit budget.")
+	fs.DurationVar(&o.pollFrequency, "poll-frequency", 5*time.Second, "Polling frequency to pipeline.")
+	fs.BoolVar(&o.adaptivePoll, "adaptive-poll", false, "Poll at -poll-frequency as the pipeline starts and nears its usual duration, backing off in between.")
+	fs.DurationVar(&o.maxPollInterval, "max-poll-interval", time.Minute, "Longest interval between polls when -adaptive-poll is set.")
+	fs.StringVar(&o.metricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on at /metrics, e.g. localhost:9090.")
+	fs.StringVar(&o.trace.OTLPEndpoint, "otlp-endpoint", o.fromEnv("otlp-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", ""), "OTLP/HTTP endpoint to export traces of completed pipelines to (env=OTEL_EXPORTER_OTLP_ENDPOINT).")
+	fs.StringVar(&o.trace.File, "trace-file", "", "File to append traces of completed pipelines to as OTLP JSON.")
+	fs.StringVar(&o.githubSource, "github-source", githubSourceActions, "Where GitHub pipelines are read from: one of actions, for workflow runs, or checks, for a commit's check runs and statuses.")
+	fs.BoolVar(&o.mergeRequest, "pr", false, "Watch the pipeline of the checked out branch's open merge or pull request rather than of HEAD.")
+	fs.DurationVar(&o.downstreamWait, "downstream-timeout", time.Hour, "Longest to wait for downstream pipelines once the watched pipeline completes.")
+	fs.IntVar(&o.excerptLines, "excerpt-lines", 20, "Most lines of each failed job's log to show the errors of, or 0 to not fetch job logs.")
+	fs.BoolVar(&o.validateToken, "validate-token", true, "Check the access token's validity, scopes and expiry before watching.")
+
+	fs.BoolVar(&o.notify.Desktop, "notify", false, "Show a desktop notification with the pipeline's details.")
+	fs.StringVar(&o.notify.On, "notify-on", string(notifier.OnComplete), "When to send notifications: one of failure, success, change or complete.")
+	fs.StringVar(&o.notify.WebhookURL, "webhook-url", "", "URL to POST a JSON payload to on pipeline events.")
+	fs.StringVar(&o.notify.WebhookTemplate, "webhook-template", "", "Path to a Go template file rendering the JSON body sent to -webhook-url.")
+	fs.StringVar(&o.notify.SlackWebhookURL, "slack-webhook-url", o.fromEnv("slack-webhook-url", "SLACK_WEBHOOK_URL", ""), "Slack incoming webhook URL to notify (env=SLACK_WEBHOOK_URL).")
+	fs.StringVar(&o.notify.TeamsWebhookURL, "teams-webhook-url", o.fromEnv("teams-webhook-url", "TEAMS_WEBHOOK_URL", ""), "Microsoft Teams incoming webhook URL to notify (env=TEAMS_WEBHOOK_URL).")
+	fs.StringVar(&o.notify.DiscordWebhookURL, "discord-webhook-url", o.fromEnv("discord-webhook-url", "DISCORD_WEBHOOK_URL", ""), "Discord webhook URL to notify (env=DISCORD_WEBHOOK_URL).")
+	fs.StringVar(&o.notify.OnSuccess, "on-success", "", "Shell command to run when the pipeline succeeds.")
+	fs.StringVar(&o.notify.OnFailure, "on-failure", "", "Shell command to run when the pipeline fails.")
+	fs.StringVar(&o.notify.OnComplete, "on-complete", "", "Shell command to run when the pipeline completes.")
+
+	// Experimental
+	fs.BoolVar(&o.playSound, "play-sound", false, "Play a noise when pipeline completes (experimental).")
+
+	return o
+}
+
+// fromEnv returns the value of an environment variable to be used as a flag's default,
+// recording that the flag was set from the environment if present.
+func (o *options) fromEnv(flagName, envName, defaultValue string) string {
+	v, ok := os.LookupEnv(envName)
+	if !ok {
+		return defaultValue
+	}
+
+	o.env[flagName] = v
+
+	return v
+}
+
+// applySettings fills in every flag that was set neither on the command-line nor through
+// its environment variable from the config file's resolved settings.
+func (o *options) applySettings(s config.Settings) {
+	explicit := make(map[string]bool)
+	o.flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	set := func(name string, dst *string, value string) {
+		_, fromEnv := o.env[name]
+		if value != "" && !explicit[name] && !fromEnv {
+			*dst = value
+		}
+	}
+
+	o.settingsToken = s.AccessToken
+	set("output", &o.output, s.Output)
+	set("notify-on", &o.notify.On, s.Notify.On)
+	set("webhook-url", &o.notify.WebhookURL, s.Notify.WebhookURL)
+	set("webhook-template", &o.notify.WebhookTemplate, s.Notify.WebhookTemplate)
+	set("slack-webhook-url", &o.notify.SlackWebhookURL, s.Notify.SlackWebhookURL)
+	set("teams-webhook-url", &o.notify.TeamsWebhookURL, s.Notify.TeamsWebhookURL)
+	set("discord-webhook-url", &o.notify.DiscordWebhookURL, s.Notify.DiscordWebhookURL)
+	set("on-success", &o.notify.OnSuccess, s.Notify.OnSuccess)
+	set("on-failure", &o.notify.OnFailure, s.Notify.OnFailure)
+	set("on-complete", &o.notify.OnComplete, s.Notify.OnComplete)
+	set("otlp-endpoint", &o.trace.OTLPEndpoint, s.Trace.OTLPEndpoint)
+	set("trace-file", &o.trace.File, s.Trace.File)
+	set("github-source", &o.githubSource, s.GitHubSource)
+
+	if s.PollFrequency != 0 && !explicit["poll-frequency"] {
+		o.pollFrequency = s.PollFrequency
+	}
+
+	if s.AdaptivePoll && !explicit["adaptive-poll"] {
+		o.adaptivePoll = true
+	}
+
+	if s.MaxPollInterval != 0 && !explicit["max-poll-interval"] {
+		o.maxPollInterval = s.MaxPollInterval
+	}
+
+	if s.Notify.Desktop && !explicit["notify"] {
+		o.notify.Desktop = true
+	}
+
+	// The provider argument is positional, so it can only have been set explicitly
+	if o.provider == "" {
+		o.provider = s.Provider
+	}
+}
+
+func (o *options) setupLogger() error {
+	level := slog.LevelInfo
+	if o.debug {
+		level = slog.LevelDebug
+	}
+
+	switch o.output {
+	case outputText:
+		// The default handler can't log below the info level
+		if o.debug {
+			slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+		}
+	case outputJSON:
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})))
+	default:
+		return fmt.Errorf("unknown output format %q: must be one of %s or %s", o.output, outputText, outputJSON)
+	}
+
+	return nil
+}
+
+// newGatewayClient determines which provider serves the remote and authenticates with it,
+// reporting the client's API usage to the metrics registry if there is one.
+func (o *options) newGatewayClient(cfg *config.Config, remoteURL string) (gateway.Client, error) { //nolint:ireturn
+	client, err := o.newProviderClient(cfg, remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if limiter, ok := client.(gateway.RateLimiter); ok && o.metrics != nil {
+		host, _ := gateway.ParseRemoteURL(remoteURL)
+		o.metrics.AddRateLimiter(host, limiter)
+	}
+
+	return client, nil
+}
+
+func (o *options) newProviderClient(cfg *config.Config, remoteURL string) (gateway.Client, error) { //nolint:ireturn
+	host, _ := gateway.ParseRemoteURL(remoteURL)
+	hostCfg, _ := cfg.Host(host)
+
+	// Tokens passed explicitly take precedence over the host's, which in turn take precedence over
+	// the config file's access_token as it may be meant for another host
+	token := o.accessToken
+	if token == "" {
+		token = hostCfg.Token
+	}
+
+	var opts []gateway.Option
+	if hostCfg.BaseURL != "" {
+		opts = append(opts, gateway.WithBaseURL(hostCfg.BaseURL))
+	}
+
+	if o.metrics != nil {
+		opts = append(opts, gateway.WithRequestObserver(o.metrics))
+	}
+
+	switch o.githubSource {
+	case githubSourceActions:
+	case githubSourceChecks:
+		opts = append(opts, gateway.WithGitHubChecks())
+	default:
+		return nil, fmt.Errorf("unknown GitHub source %q: must be one of %s or %s", o.githubSource, githubSourceActions, githubSourceChecks)
+	}
+
+	provider := o.provider
+	if provider == "" {
+		provider = hostCfg.Provider
+	}
+
+	if app := hostCfg.GitHubApp; app != nil && token == "" {
+		key, err := os.ReadFile(app.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+
+		opts = append(opts, gateway.WithGitHubApp(gateway.GitHubApp{
+			AppID:          app.AppID,
+			InstallationID: app.InstallationID,
+			PrivateKey:     key,
+		}))
+
+		return gateway.New("", gateway.GitHub, opts...)
+	}
+
+	if token == "" {
+		token = o.settingsToken
+	}
+
+	switch {
+	// If a provider was passed in or configured, use it to determine the git provider
+	case provider != "":
+		if token == "" {
+			var authOpts []gateway.Option
+			token, authOpts = gateway.LookupToken(remoteURL, gateway.ProviderType(provider))
+			opts = append(opts, authOpts...)
+		}
+
+		return gateway.New(token, gateway.ProviderType(provider), opts...)
+	// Check the access token's prefix to determine the git provider
+	case token != "":
+		return gateway.NewFromToken(token, opts...)
+	// Check the remote git URL to determine the git provider, discovering credentials for its host
+	default:
+		return gateway.NewFromRemoteURL(token, remoteURL, opts...)
+	}
+}
+
+// newService creates a service polling pipelines, fetching every job of completed pipelines
+// when they are traced and excerpts of failed jobs' logs unless disabled.
+func (o *options) newService(gw gateway.Client, gc git.Client) *checker.Service {
+	svc := checker.New(gw, gc).WithDownstreamTimeout(o.downstreamWait)
+	if o.trace.OTLPEndpoint != "" || o.trace.File != "" {
+		svc.WithAllJobs()
+	}
+
+	if o.excerptLines > 0 {
+		svc.WithLogExcerpts(excerpt.Default(o.excerptLines))
+	}
+
+	return svc
+}
+
+// scheduler returns how a pipeline's polls are scheduled.
+func (o *options) scheduler(svc *checker.Service) func(*gateway.Pipeline) checker.Scheduler {
+	if !o.adaptivePoll {
+		return func(*gateway.Pipeline) checker.Scheduler {
+			return checker.FixedScheduler(o.pollFrequency)
+		}
+	}
+
+	return func(pipeline *gateway.Pipeline) checker.Scheduler {
+		return svc.AdaptiveScheduler(pipeline, o.pollFrequency, o.maxPollInterval)
+	}
+}
+
+func (o *options) notifiers() (notifier.Multi, error) {
+	trigger, err := notifier.ParseTrigger(o.notify.On)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifiers notifier.Multi
+	if o.metrics != nil {
+		notifiers = append(notifiers, o.metrics)
+	}
+
+	if o.notify.Desktop {
+		notifiers = append(notifiers, notifier.WithTrigger(trigger, notifier.NewDesktop()))
+	}
+
+	if o.notify.WebhookURL != "" {
+		webhook, err := newWebhook(o.notify.WebhookURL, o.notify.WebhookTemplate)
+		if err != nil {
+			return nil, err
+		}
+
+		notifiers = append(notifiers, notifier.WithTrigger(trigger, webhook))
+	}
+
+	if o.notify.SlackWebhookURL != "" {
+		notifiers = append(notifiers, notifier.WithTrigger(trigger, notifier.NewSlack(o.notify.SlackWebhookURL)))
+	}
+
+	if o.notify.TeamsWebhookURL != "" {
+		notifiers = append(notifiers, notifier.WithTrigger(trigger, notifier.NewTeams(o.notify.TeamsWebhookURL)))
+	}
+
+	if o.notify.DiscordWebhookURL != "" {
+		notifiers = append(notifiers, notifier.WithTrigger(trigger, notifier.NewDiscord(o.notify.DiscordWebhookURL)))
+	}
+
+	// Traces are exported for every completed pipeline, independent of -notify-on
+	if o.trace.OTLPEndpoint != "" {
+		headers, err := tracing.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+		if err != nil {
+			return nil, err
+		}
+
+		exporter, err := tracing.NewHTTPExporter(o.trace.OTLPEndpoint, headers)
+		if err != nil {
+			return nil, err
+		}
+
+		notifiers = append(notifiers, exporter)
+	}
+
+	if o.trace.File != "" {
+		notifiers = append(notifiers, tracing.NewFileExporter(o.trace.File))
+	}
+
+	// Hooks run on their own events, independent of -notify-on
+	for _, hook := range []struct {
+		trigger notifier.Trigger
+		command string
+	}{
+		{notifier.OnSuccess, o.notify.OnSuccess},
+		{notifier.OnFailure, o.notify.OnFailure},
+		{notifier.OnComplete, o.notify.OnComplete},
+	} {
+		if hook.command != "" {
+			notifiers = append(notifiers, notifier.WithTrigger(hook.trigger, notifier.NewCommand(hook.command)))
+		}
+	}
+
+	return notifiers, nil
+}
+
+// serveMetrics serves Prometheus metrics in the background if -metrics-addr is set.
+func (o *options) serveMetrics() {
+	if o.metricsAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", o.metrics)
+
+	server := &http.Server{
+		Addr:              o.metricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			slog.Error("failed to serve metrics", slog.Any("error", err))
+		}
+	}()
+}
+
+func newWebhook(url, templatePath string) (*notifier.Webhook, error) {
+	if templatePath == "" {
+		return notifier.NewWebhook(url, "")
+	}
+
+	tmpl, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook template: %w", err)
+	}
+
+	return notifier.NewWebhook(url, string(tmpl))
+}
+
+func defaultConfigPath() string {
+	p, err := config.GlobalPath()
+	if err != nil {
+		return ""
+	}
+
+	return p
+}